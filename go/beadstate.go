@@ -0,0 +1,172 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stateKeyRe restricts bead state keys to what survives the KEY=VALUE form
+// of `bd set-state`: lowercase, starting with a letter, no "=" or spaces.
+var stateKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// ValidStateKey returns true if key can be used with BeadStateSet.
+func ValidStateKey(key string) bool {
+	return stateKeyRe.MatchString(key)
+}
+
+// BeadStateSet records key=value on bead via `bd set-state`, e.g.
+// BeadStateSet(bead, "review", "pending"). A reason is passed as --reason.
+// Silent no-op without bd; invalid keys and bd failures are logged.
+// See BeadStateSetE for a variant that returns the error.
+func BeadStateSet(bead, key, value string, reason ...string) {
+	defaultClient.BeadStateSet(bead, key, value, reason...)
+}
+
+// BeadStateSet is BeadStateSet for c.
+func (c *Client) BeadStateSet(bead, key, value string, reason ...string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.BeadStateSetContext(ctx, bead, key, value, reason...)
+}
+
+// BeadStateSetContext is BeadStateSet bounded by ctx.
+func BeadStateSetContext(ctx context.Context, bead, key, value string, reason ...string) {
+	defaultClient.BeadStateSetContext(ctx, bead, key, value, reason...)
+}
+
+// BeadStateSetContext is BeadStateSetContext for c.
+func (c *Client) BeadStateSetContext(ctx context.Context, bead, key, value string, reason ...string) {
+	c.logFailure(c.BeadStateSetContextE(ctx, bead, key, value, reason...), "bead", bead, "key", key)
+}
+
+// BeadStateSetE is BeadStateSet that reports what happened: ErrNotAvailable
+// without bd, a *CommandError if bd failed (ErrTimeout if it timed out), or
+// an error naming the invalid key.
+func BeadStateSetE(bead, key, value string, reason ...string) error {
+	return defaultClient.BeadStateSetE(bead, key, value, reason...)
+}
+
+// BeadStateSetE is BeadStateSetE for c.
+func (c *Client) BeadStateSetE(bead, key, value string, reason ...string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.BeadStateSetContextE(ctx, bead, key, value, reason...)
+}
+
+// BeadStateSetContextE is BeadStateSetE bounded by ctx.
+func BeadStateSetContextE(ctx context.Context, bead, key, value string, reason ...string) error {
+	return defaultClient.BeadStateSetContextE(ctx, bead, key, value, reason...)
+}
+
+// BeadStateSetContextE is BeadStateSetContextE for c.
+func (c *Client) BeadStateSetContextE(ctx context.Context, bead, key, value string, reason ...string) error {
+	if !c.HasBD() {
+		return notAvailable("bd")
+	}
+	if !ValidStateKey(key) {
+		return fmt.Errorf("bd set-state skipped: invalid state key %q", key)
+	}
+	why := ""
+	if len(reason) > 0 {
+		why = reason[0]
+	}
+	return c.stateSet(ctx, bead, key, value, why)
+}
+
+// stateSet runs `bd set-state BEAD KEY=VALUE [--reason WHY]`.
+func (c *Client) stateSet(ctx context.Context, bead, key, value, why string) error {
+	args := []string{"set-state", bead, fmt.Sprintf("%s=%s", key, value)}
+	if why != "" {
+		args = append(args, "--reason", why)
+	}
+	_, err := c.mutate(ctx, "bd set-state", "bd", args...)
+	return err
+}
+
+// BeadStateGet returns the value of key on bead via `bd state BEAD KEY`.
+// Returns "" if bd is missing, fails, the key is invalid, or it is unset.
+func BeadStateGet(bead, key string) string {
+	return defaultClient.BeadStateGet(bead, key)
+}
+
+// BeadStateGet is BeadStateGet for c.
+func (c *Client) BeadStateGet(bead, key string) string {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.BeadStateGetContext(ctx, bead, key)
+}
+
+// BeadStateGetContext is BeadStateGet bounded by ctx.
+func BeadStateGetContext(ctx context.Context, bead, key string) string {
+	return defaultClient.BeadStateGetContext(ctx, bead, key)
+}
+
+// BeadStateGetContext is BeadStateGetContext for c.
+func (c *Client) BeadStateGetContext(ctx context.Context, bead, key string) string {
+	if bead == "" || !ValidStateKey(key) || !c.HasBD() {
+		return ""
+	}
+	out, _, err := c.run(ctx, "bd", "state", bead, key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// BeadStateList returns every state key/value on bead via
+// `bd state BEAD --json`. Returns nil if bd is missing or fails.
+func BeadStateList(bead string) map[string]string {
+	return defaultClient.BeadStateList(bead)
+}
+
+// BeadStateList is BeadStateList for c.
+func (c *Client) BeadStateList(bead string) map[string]string {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.BeadStateListContext(ctx, bead)
+}
+
+// BeadStateListContext is BeadStateList bounded by ctx.
+func BeadStateListContext(ctx context.Context, bead string) map[string]string {
+	return defaultClient.BeadStateListContext(ctx, bead)
+}
+
+// BeadStateListContext is BeadStateListContext for c.
+func (c *Client) BeadStateListContext(ctx context.Context, bead string) map[string]string {
+	if bead == "" || !c.HasBD() {
+		return nil
+	}
+	out, _, err := c.run(ctx, "bd", "state", bead, "--json")
+	if err != nil {
+		return nil
+	}
+	return parseStateList(out)
+}
+
+// parseStateList accepts a JSON object of key/value pairs, or one KEY=VALUE
+// per line as older bd builds print.
+func parseStateList(out []byte) map[string]string {
+	var obj map[string]any
+	if err := json.Unmarshal(out, &obj); err == nil {
+		state := make(map[string]string, len(obj))
+		for k, v := range obj {
+			if s, ok := v.(string); ok {
+				state[k] = s
+			} else {
+				b, _ := json.Marshal(v)
+				state[k] = string(b)
+			}
+		}
+		return state
+	}
+	state := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok && ValidStateKey(k) {
+			state[k] = v
+		}
+	}
+	return state
+}
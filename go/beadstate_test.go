@@ -0,0 +1,78 @@
+package interbase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidStateKey(t *testing.T) {
+	for _, k := range []string{"phase", "review", "owner", "ci.status", "retry_count"} {
+		if !ValidStateKey(k) {
+			t.Errorf("ValidStateKey(%q) = false, want true", k)
+		}
+	}
+	for _, k := range []string{"", "Phase", "1st", "a=b", "has space", "-x"} {
+		if ValidStateKey(k) {
+			t.Errorf("ValidStateKey(%q) = true, want false", k)
+		}
+	}
+}
+
+func TestBeadStateSet(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd set-state iv-1 review=pending":                    {},
+		"bd set-state iv-1 owner=agent-x --reason handed off": {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	c.BeadStateSet("iv-1", "review", "pending")
+	c.BeadStateSet("iv-1", "owner", "agent-x", "handed off")
+	c.BeadStateSet("iv-1", "bad=key", "x")
+	want := []string{
+		"bd set-state iv-1 review=pending",
+		"bd set-state iv-1 owner=agent-x --reason handed off",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestBeadStateGetAndList(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd state iv-1 review": {stdout: "pending\n"},
+		"bd state iv-1 --json": {stdout: `{"phase":"planned","review":"pending","retries":2}`},
+		"bd state iv-2 --json": {stdout: "phase=executing\nowner=agent-x\n"},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	if got := c.BeadStateGet("iv-1", "review"); got != "pending" {
+		t.Errorf("BeadStateGet() = %q, want pending", got)
+	}
+	if got := c.BeadStateGet("iv-1", "Bad Key"); got != "" {
+		t.Errorf("BeadStateGet() with invalid key = %q, want empty", got)
+	}
+	want := map[string]string{"phase": "planned", "review": "pending", "retries": "2"}
+	if got := c.BeadStateList("iv-1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("BeadStateList(iv-1) = %v, want %v", got, want)
+	}
+	want = map[string]string{"phase": "executing", "owner": "agent-x"}
+	if got := c.BeadStateList("iv-2"); !reflect.DeepEqual(got, want) {
+		t.Errorf("BeadStateList(iv-2) = %v, want %v", got, want)
+	}
+}
+
+func TestBeadState_NoBD(t *testing.T) {
+	r := &fakeRunner{}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath()), WithRunner(r))
+
+	c.BeadStateSet("iv-1", "review", "pending")
+	if got := c.BeadStateGet("iv-1", "review"); got != "" {
+		t.Errorf("BeadStateGet() without bd = %q, want empty", got)
+	}
+	if got := c.BeadStateList("iv-1"); got != nil {
+		t.Errorf("BeadStateList() without bd = %v, want nil", got)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("runner calls = %q, want none without bd", r.calls)
+	}
+}
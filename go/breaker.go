@@ -0,0 +1,161 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Breaker defaults applied when BreakerConfig leaves a field zero.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 2 * time.Minute
+)
+
+// BreakerConfig tunes the per-tool circuit breaker. Zero fields use the
+// defaults.
+type BreakerConfig struct {
+	Threshold int           // consecutive failures that open it (DefaultBreakerThreshold)
+	Cooldown  time.Duration // how long it stays open (DefaultBreakerCooldown)
+}
+
+// breakerState is the persisted state of one tool's breaker.
+type breakerState struct {
+	Failures  int       `json:"failures"`
+	OpenUntil time.Time `json:"open_until"`
+}
+
+// EnableBreaker puts a circuit breaker in front of ic and bd. After
+// Threshold consecutive failed actions of a tool its breaker opens for
+// Cooldown: every call to that tool, read or action, is skipped as
+// ErrNotAvailable (and ErrCircuitOpen), so guards fail open and actions are
+// silent no-ops. The first action after the cooldown goes through; success
+// closes the breaker, failure reopens it. Off by default.
+//
+// Only actions feed the breaker. A failure is an action that failed for good
+// (counted once, after any retries), timed out or crashed. Transient failures
+// such as "database is locked" are not, nor is a call whose context the
+// caller cancelled. Reads neither count nor close it: a guard that answers
+// says nothing about whether the last writes went through.
+//
+// State lives in breaker-TOOL.json under the interverse state dir so that
+// short-lived hook processes share it. Updates are best effort: concurrent
+// failures in separate processes may be counted once.
+func EnableBreaker(cfg BreakerConfig) {
+	defaultClient.EnableBreaker(cfg)
+}
+
+// EnableBreaker is EnableBreaker for c.
+func (c *Client) EnableBreaker(cfg BreakerConfig) {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultBreakerThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultBreakerCooldown
+	}
+	c.breaker.Store(&cfg)
+}
+
+// DisableBreaker stops skipping calls. Persisted state is kept and applies
+// again once a breaker is enabled.
+func DisableBreaker() {
+	defaultClient.DisableBreaker()
+}
+
+// DisableBreaker is DisableBreaker for c.
+func (c *Client) DisableBreaker() {
+	c.breaker.Store(nil)
+}
+
+// BreakerOpen reports whether calls to tool ("ic" or "bd") are currently
+// being skipped. Always false while the breaker is disabled.
+func BreakerOpen(tool string) bool {
+	return defaultClient.BreakerOpen(tool)
+}
+
+// BreakerOpen is BreakerOpen for c.
+func (c *Client) BreakerOpen(tool string) bool {
+	if c.breaker.Load() == nil {
+		return false
+	}
+	return c.now().Before(readBreaker(c.breakerPath(tool)).OpenUntil)
+}
+
+// breakerPath is the state file of tool's breaker.
+func (c *Client) breakerPath(tool string) string {
+	return filepath.Join(c.userConfigDir(), "interverse", "breaker-"+tool+".json")
+}
+
+// circuitOpen is the error for a call skipped by tool's open breaker.
+func circuitOpen(tool string) error {
+	return fmt.Errorf("%s %w (%w)", tool, ErrNotAvailable, ErrCircuitOpen)
+}
+
+// observe feeds the outcome of one action on tool into its breaker. Transient
+// failures (lock contention, see ErrTransient) do not count, except timeouts:
+// a tool that hangs is what the breaker is for. A call the caller cancelled
+// says nothing about the tool and does not count either.
+func (c *Client) observe(tool string, err error) {
+	cfg := c.breaker.Load()
+	if cfg == nil || c.dryRun.Load() != nil {
+		return
+	}
+	path := c.breakerPath(tool)
+	s := readBreaker(path)
+	if err == nil {
+		if s.Failures > 0 {
+			os.Remove(path)
+		}
+		return
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, exec.ErrNotFound) || errors.Is(err, context.Canceled) {
+		return
+	}
+	if errors.Is(err, ErrTransient) && !errors.Is(err, ErrTimeout) {
+		return
+	}
+
+	s.Failures++
+	if s.Failures >= cfg.Threshold {
+		s.OpenUntil = c.now().Add(cfg.Cooldown)
+		c.log().Warn(tool+" circuit breaker open, skipping calls", "failures", s.Failures, "until", s.OpenUntil.Format(time.RFC3339), "error", err)
+	}
+	if err := writeBreaker(path, s); err != nil {
+		c.log().Debug("circuit breaker state not recorded", "path", path, "error", err)
+	}
+}
+
+func readBreaker(path string) breakerState {
+	var s breakerState
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &s)
+	}
+	return s
+}
+
+// writeBreaker replaces path with s, atomically.
+func writeBreaker(path string, s breakerState) error {
+	data, _ := json.Marshal(s)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
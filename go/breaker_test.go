@@ -0,0 +1,203 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func breakerClient(t *testing.T, dir string, r Runner, now *time.Time, tools ...string) *Client {
+	t.Helper()
+	return New(
+		WithEnv(map[string]string{"XDG_CONFIG_HOME": dir}),
+		WithLookPath(onPath(tools...)),
+		WithRunner(r),
+		WithClock(func() time.Time { return *now }),
+		WithBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute}),
+	)
+}
+
+func TestBreaker_OpensAndPersists(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, calls := flakyRunner(100, "corrupt database", 1)
+	c := breakerClient(t, dir, r, &now, "bd")
+
+	for range 2 {
+		var ce *CommandError
+		if err := c.PhaseSetE("iv-1", "executing"); !errors.As(err, &ce) {
+			t.Fatalf("PhaseSetE = %v, want *CommandError", err)
+		}
+	}
+	err := c.PhaseSetE("iv-1", "executing")
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("PhaseSetE with open breaker = %v, want ErrCircuitOpen and ErrNotAvailable", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2: the open breaker must skip bd", n)
+	}
+
+	// A fresh client, as in the next hook process, sees the same state.
+	next := breakerClient(t, dir, r, &now, "bd")
+	caps := next.Detect()
+	if !caps.BDBreakerOpen || caps.ICBreakerOpen {
+		t.Errorf("Detect() breakers = ic %v, bd %v, want only bd open", caps.ICBreakerOpen, caps.BDBreakerOpen)
+	}
+	next.DisableBreaker()
+	if next.BreakerOpen("bd") {
+		t.Error("BreakerOpen with the breaker disabled = true")
+	}
+}
+
+func TestBreaker_CooldownThenClose(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, calls := flakyRunner(2, "", 1)
+	c := breakerClient(t, dir, r, &now, "bd")
+
+	c.PhaseSet("iv-1", "executing")
+	c.PhaseSet("iv-1", "executing")
+	if !c.BreakerOpen("bd") {
+		t.Fatal("breaker not open after 2 failures")
+	}
+
+	now = now.Add(time.Minute)
+	if err := c.PhaseSetE("iv-1", "executing"); err != nil {
+		t.Errorf("PhaseSetE after cooldown = %v, want success", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+	if _, err := os.Stat(c.breakerPath("bd")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("breaker state after success: err = %v, want removed", err)
+	}
+}
+
+func TestBreaker_ReadAnswersDoNotCount(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var calls atomic.Int32
+	r := RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		calls.Add(1)
+		return nil, nil, exitError(1) // e.g. ic run current with no active run
+	})
+	c := breakerClient(t, dir, r, &now, "bd", "ic")
+
+	for range 5 {
+		c.Refresh()
+		c.InSprint()
+		c.PhaseGet("iv-1")
+	}
+	if calls.Load() == 0 {
+		t.Fatal("no reads ran")
+	}
+	if c.BreakerOpen("ic") || c.BreakerOpen("bd") {
+		t.Error("non-zero exits of reads opened a breaker")
+	}
+}
+
+func TestBreaker_ReadsDoNotClose(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &fakeRunner{results: map[string]fakeResult{
+		`bd state iv-1 phase`:                     {stdout: "planned\n"},
+		`bd set-state iv-1 phase=executing`:       {err: exitError(1)},
+		`ic --version`:                            {stdout: "ic 0.4.2\n"},
+		`ic events emit run-1 a.one --payload={}`: {err: exitError(1)},
+	}}
+	c := breakerClient(t, dir, r, &now, "bd", "ic")
+
+	c.BeadStateSet("iv-1", "phase", "executing")
+	c.PhaseGet("iv-1")
+	c.EmitEvent("run-1", "a.one")
+	c.HasICVersion("0.1.0")
+	c.BeadStateSet("iv-1", "phase", "executing")
+	c.EmitEvent("run-1", "a.one")
+	if !c.BreakerOpen("bd") || !c.BreakerOpen("ic") {
+		t.Errorf("breakers after 2 failed actions with good reads between = bd %v, ic %v, want both open",
+			c.BreakerOpen("bd"), c.BreakerOpen("ic"))
+	}
+}
+
+func TestBreaker_TimeoutsCount(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		<-ctx.Done()
+		return nil, nil, errors.New("signal: killed")
+	})
+	c := breakerClient(t, dir, r, &now, "bd")
+	c.SetTimeout(10 * time.Millisecond)
+
+	c.BeadStateSet("iv-1", "owner", "me")
+	c.BeadStateSet("iv-1", "owner", "me")
+	if !c.BreakerOpen("bd") {
+		t.Error("breaker not open after 2 timed-out actions")
+	}
+}
+
+func TestBreaker_TransientRetriesDoNotCount(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, calls := flakyRunner(100, "Error: database is locked", 1)
+	c := breakerClient(t, dir, r, &now, "bd")
+	c.EnableRetry(RetryPolicy{BaseDelay: time.Millisecond})
+
+	for range 3 {
+		if err := c.BeadStateSetE("iv-1", "owner", "me"); !errors.Is(err, ErrTransient) {
+			t.Fatalf("BeadStateSetE = %v, want ErrTransient after retries", err)
+		}
+	}
+	if n := calls.Load(); n != 9 {
+		t.Errorf("calls = %d, want every retry attempted (9)", n)
+	}
+	if c.BreakerOpen("bd") {
+		t.Error("lock contention opened the breaker")
+	}
+}
+
+func TestBreaker_OneFailurePerCall(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var calls atomic.Int32
+	r := RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		if calls.Add(1) < 3 {
+			return nil, []byte("database is locked"), exitError(1)
+		}
+		return nil, []byte("database disk image is malformed"), exitError(1)
+	})
+	c := breakerClient(t, dir, r, &now, "bd")
+	c.EnableRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	c.BeadStateSet("iv-1", "owner", "me")
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 2 retried locks and a final failure", n)
+	}
+	if s := readBreaker(c.breakerPath("bd")); s.Failures != 1 {
+		t.Errorf("breaker failures after one call = %d, want 1", s.Failures)
+	}
+}
+
+func TestBreaker_CancelledCallsDoNotCount(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		<-ctx.Done()
+		return nil, nil, errors.New("signal: killed")
+	})
+	c := breakerClient(t, dir, r, &now, "bd")
+
+	for range 3 {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := c.BeadStateSetContextE(ctx, "iv-1", "owner", "me"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("BeadStateSetContextE = %v, want context.Canceled", err)
+		}
+	}
+	if c.BreakerOpen("bd") {
+		t.Error("calls cancelled by the caller opened the breaker")
+	}
+}
@@ -0,0 +1,101 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+)
+
+// Capabilities is a point-in-time snapshot of everything the guards report.
+// Every field is always present in the JSON form so consumers can rely on a
+// fixed shape; Companions is sorted and never null.
+type Capabilities struct {
+	HasIC         bool     `json:"has_ic"`
+	HasBD         bool     `json:"has_bd"`
+	InEcosystem   bool     `json:"in_ecosystem"`
+	InSprint      bool     `json:"in_sprint"`
+	Bead          string   `json:"bead"`
+	EcosystemRoot string   `json:"ecosystem_root"`
+	Companions    []string `json:"companions"`
+	ICVersion     string   `json:"ic_version"`
+	BDVersion     string   `json:"bd_version"`
+	ICBreakerOpen bool     `json:"ic_breaker_open"` // see EnableBreaker
+	BDBreakerOpen bool     `json:"bd_breaker_open"`
+}
+
+// Detect gathers all guard results in one pass. Fail-open like the guards it
+// wraps: missing tools yield false/empty fields, never an error.
+func Detect() Capabilities {
+	return defaultClient.Detect()
+}
+
+// Detect is Detect for c.
+func (c *Client) Detect() Capabilities {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.DetectContext(ctx)
+}
+
+// DetectContext is Detect bounded by ctx.
+func DetectContext(ctx context.Context) Capabilities {
+	return defaultClient.DetectContext(ctx)
+}
+
+// DetectContext is DetectContext for c.
+func (c *Client) DetectContext(ctx context.Context) Capabilities {
+	caps := Capabilities{
+		HasIC:         c.HasIC(),
+		HasBD:         c.HasBD(),
+		InEcosystem:   c.InEcosystem(),
+		Bead:          c.GetBead(),
+		EcosystemRoot: c.EcosystemRoot(),
+		Companions:    c.installedCompanions(),
+		ICBreakerOpen: c.BreakerOpen("ic"),
+		BDBreakerOpen: c.BreakerOpen("bd"),
+	}
+	if caps.HasIC {
+		caps.InSprint = caps.Bead != "" && c.icRunActive(ctx)
+		caps.ICVersion = c.toolVersion(ctx, "ic")
+	}
+	if caps.HasBD {
+		caps.BDVersion = c.toolVersion(ctx, "bd")
+	}
+	return caps
+}
+
+// JSON returns the snapshot as a JSON string for logging or shipping.
+func (c Capabilities) JSON() string {
+	if c.Companions == nil {
+		c.Companions = []string{}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// installedCompanions lists the distinct plugin names in the Claude Code
+// cache (~/.claude/plugins/cache/MARKETPLACE/NAME/VERSION), sorted.
+func (c *Client) installedCompanions() []string {
+	names := []string{}
+	root := c.pluginCacheDir()
+	if root == "" {
+		return names
+	}
+	matches, err := c.glob(filepath.Join(root, "*", "*", "*"))
+	if err != nil {
+		return names
+	}
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		name := filepath.Base(filepath.Dir(m))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
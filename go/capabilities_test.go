@@ -0,0 +1,50 @@
+package interbase
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetect_Snapshot(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	home := t.TempDir()
+	for _, p := range []string{"mp-a/interflux/1.0.0", "mp-b/interflux/1.2.0", "mp-a/interlock/0.3.0"} {
+		os.MkdirAll(filepath.Join(home, ".claude", "plugins", "cache", p), 0755)
+	}
+	bin := t.TempDir()
+	writeFakeBin(t, bin, "ic", `[ "$1" = "--version" ] && echo "ic version 0.4.2"; exit 0`)
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", bin)
+	t.Setenv("CLAVAIN_BEAD_ID", "iv-abc")
+	t.Setenv("DEMARCH_ROOT", "/test/demarch")
+	t.Setenv("INTERMOD_LIB", "/nonexistent/interbase.sh")
+
+	got := Detect()
+	want := Capabilities{
+		HasIC:         true,
+		InSprint:      true,
+		Bead:          "iv-abc",
+		EcosystemRoot: "/test/demarch",
+		Companions:    []string{"interflux", "interlock"},
+		ICVersion:     "0.4.2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCapabilities_JSONStable(t *testing.T) {
+	got := Capabilities{}.JSON()
+	want := `{"has_ic":false,"has_bd":false,"in_ecosystem":false,"in_sprint":false,"bead":"","ecosystem_root":"","companions":[],"ic_version":"","bd_version":"","ic_breaker_open":false,"bd_breaker_open":false}`
+	if got != want {
+		t.Errorf("JSON() = %s, want %s", got, want)
+	}
+	var round Capabilities
+	if err := json.Unmarshal([]byte(got), &round); err != nil {
+		t.Fatalf("JSON() not parseable: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mistakeknot/interbase/go/events"
+)
+
+// Client is an isolated interbase instance. Every guard, action and
+// discovery function is available as a Client method; the package-level
+// functions delegate to a default Client that reads the real process
+// environment, filesystem and PATH. Tests and multi-tenant hosts can build
+// their own Clients with New and run them side by side.
+type Client struct {
+	getenv   func(string) string
+	environ  []string // nil inherits the process environment
+	fsys     fs.FS    // nil means direct OS calls; see WithFS
+	home     string
+	lookPath func(string) (string, error)
+	runner   atomic.Pointer[runnerHolder]
+	now      func() time.Time
+	probes   *probeCache
+	timeout  atomic.Int64
+
+	envelope atomic.Pointer[envelopeConfig]
+	events   *events.Registry
+	outbox   atomic.Pointer[OutboxConfig]
+	dryRun   atomic.Pointer[dryRun]
+	retry    atomic.Pointer[RetryPolicy]
+	breaker  atomic.Pointer[BreakerConfig]
+
+	logger    atomic.Pointer[slog.Logger] // nil means stderrLog
+	logLevel  atomic.Int64                // noLevel means derived, see level
+	stderrLog *slog.Logger
+
+	graphMu sync.RWMutex
+	graph   PhaseGraph // nil means DefaultPhaseGraph
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithEnv replaces the process environment with env. It is used for every
+// variable lookup, for PATH resolution (unless WithLookPath is given) and as
+// the environment of subprocesses started by the default runner.
+func WithEnv(env map[string]string) Option {
+	return func(c *Client) {
+		env = cloneEnv(env)
+		c.getenv = func(key string) string { return env[key] }
+		c.environ = environList(env)
+	}
+}
+
+// WithFS sets the filesystem used for discovery reads (plugin cache,
+// centralized install, ecosystem root); without it they use the OS directly.
+// fsys is treated as rooted at "/": absolute path /a/b is read as "a/b".
+// Nudge state is still written to the real filesystem, under the config dir
+// derived from the Client's env.
+func WithFS(fsys fs.FS) Option {
+	return func(c *Client) { c.fsys = fsys }
+}
+
+// WithHomeDir overrides the home directory. By default it is $HOME from the
+// Client's env, or os.UserHomeDir for the process environment.
+func WithHomeDir(dir string) Option {
+	return func(c *Client) { c.home = dir }
+}
+
+// WithLookPath overrides how ic and bd are found on PATH.
+func WithLookPath(fn func(file string) (string, error)) Option {
+	return func(c *Client) { c.lookPath = fn }
+}
+
+// WithRunner overrides how ic and bd subprocesses are executed (default
+// ExecRunner). See also SetRunner.
+func WithRunner(r Runner) Option {
+	return func(c *Client) { c.runner.Store(&runnerHolder{r}) }
+}
+
+// WithEnvelope enables the event envelope, attributing events to plugin.
+func WithEnvelope(plugin string) Option {
+	return func(c *Client) { c.EnableEnvelope(plugin) }
+}
+
+// WithEventRegistry sets the registry EmitEvent validates payloads against
+// (default events.Default).
+func WithEventRegistry(r *events.Registry) Option {
+	return func(c *Client) { c.events = r }
+}
+
+// WithOutbox enables the event outbox with cfg's caps.
+func WithOutbox(cfg OutboxConfig) Option {
+	return func(c *Client) { c.EnableOutbox(cfg) }
+}
+
+// WithRetry enables retrying transient action failures under p.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) { c.EnableRetry(p) }
+}
+
+// WithBreaker enables the ic/bd circuit breaker with cfg.
+func WithBreaker(cfg BreakerConfig) Option {
+	return func(c *Client) { c.EnableBreaker(cfg) }
+}
+
+// WithDryRun puts the Client in dry-run mode, printing planned actions to w
+// (see EnableDryRun).
+func WithDryRun(w io.Writer) Option {
+	return func(c *Client) { c.EnableDryRun(w) }
+}
+
+// WithLogger routes the Client's diagnostics to l (see SetLogger).
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) { c.logger.Store(l) }
+}
+
+// WithLogLevel sets the level of the Client's built-in stderr logger (see
+// SetLogLevel).
+func WithLogLevel(level slog.Level) Option {
+	return func(c *Client) { c.logLevel.Store(int64(level)) }
+}
+
+// WithPhaseGraph sets the transition graph PhaseTransition enforces
+// (default DefaultPhaseGraph).
+func WithPhaseGraph(g PhaseGraph) Option {
+	return func(c *Client) { c.graph = g.clone() }
+}
+
+// WithClock overrides the time source used for probe cache expiry and
+// envelope timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) { c.now = now }
+}
+
+// WithProbeTTL sets the Client's probe cache TTL (default DefaultProbeTTL).
+func WithProbeTTL(ttl time.Duration) Option {
+	return func(c *Client) { c.probes.setTTL(ttl) }
+}
+
+// WithTimeout sets the Client's subprocess timeout (default DefaultTimeout).
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout.Store(int64(d)) }
+}
+
+// New creates a Client. Without options it behaves exactly like the
+// package-level functions.
+func New(opts ...Option) *Client {
+	c := &Client{
+		getenv: os.Getenv,
+		now:    time.Now,
+		events: events.Default,
+		probes: newProbeCache(DefaultProbeTTL),
+	}
+	c.timeout.Store(int64(DefaultTimeout))
+	c.logLevel.Store(noLevel)
+	c.stderrLog = slog.New(newStderrHandler(c.level))
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.probes.now = c.now
+	if c.dryRun.Load() == nil && dryRunFromEnv(c.getenv("INTERBASE_DRY_RUN")) {
+		c.EnableDryRun(os.Stderr)
+	}
+	if c.lookPath == nil {
+		if c.environ == nil {
+			c.lookPath = exec.LookPath
+		} else {
+			c.lookPath = func(file string) (string, error) {
+				return lookPathIn(file, c.getenv("PATH"))
+			}
+		}
+	}
+	if c.runner.Load() == nil {
+		c.runner.Store(&runnerHolder{c.execRunner()})
+	}
+	return c
+}
+
+// defaultClient backs the package-level functions.
+var defaultClient = New()
+
+// --- Environment access ---
+
+func (c *Client) homeDir() (string, error) {
+	if c.home != "" {
+		return c.home, nil
+	}
+	if c.environ == nil {
+		return os.UserHomeDir()
+	}
+	if home := c.getenv("HOME"); home != "" {
+		return home, nil
+	}
+	return "", errors.New("$HOME is not defined")
+}
+
+// stat stats an OS path, through c's filesystem if WithFS gave one.
+func (c *Client) stat(name string) (fs.FileInfo, error) {
+	if c.fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(c.fsys, fsPath(name))
+}
+
+// glob matches an OS path pattern, through c's filesystem if WithFS gave
+// one, and returns OS paths.
+func (c *Client) glob(pattern string) ([]string, error) {
+	if c.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	matches, err := fs.Glob(c.fsys, fsPath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		matches[i] = filepath.FromSlash("/" + m)
+	}
+	return matches, nil
+}
+
+// fsPath converts an OS path into a path within a filesystem rooted at "/".
+func fsPath(name string) string {
+	if !filepath.IsAbs(name) {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	p := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// run executes name via c's runner for a read, reporting a context error in
+// place of the kill signal when ctx expired. It is skipped while name's
+// circuit breaker is open but does not feed the breaker. Actions use runE.
+func (c *Client) run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error) {
+	if c.BreakerOpen(name) {
+		return nil, nil, circuitOpen(name)
+	}
+	stdout, stderr, err = c.runner.Load().Run(ctx, name, args...)
+	return stdout, stderr, runErr(ctx, err)
+}
+
+// lookPathIn is exec.LookPath against an explicit PATH value.
+func lookPathIn(file, pathList string) (string, error) {
+	if strings.Contains(file, string(filepath.Separator)) {
+		if isExecutable(file) {
+			return file, nil
+		}
+		return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+	}
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, file)
+		if isExecutable(path) {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+func cloneEnv(env map[string]string) map[string]string {
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = v
+	}
+	return out
+}
+
+// environList renders env as sorted KEY=VALUE pairs for exec.Cmd.Env.
+func environList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
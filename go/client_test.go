@@ -0,0 +1,164 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeRunner records calls and answers from a canned table keyed by the
+// joined command line.
+type fakeRunner struct {
+	mu      sync.Mutex
+	calls   []string
+	results map[string]fakeResult
+}
+
+type fakeResult struct {
+	stdout string
+	err    error
+}
+
+func (r *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, line)
+	res, ok := r.results[line]
+	if !ok {
+		return nil, nil, errors.New("unexpected command: " + line)
+	}
+	return []byte(res.stdout), nil, res.err
+}
+
+func onPath(names ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, n := range names {
+			if n == file {
+				return "/fake/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestClient_Isolated(t *testing.T) {
+	fsys := fstest.MapFS{
+		"home/a/.claude/plugins/cache/mp/interflux/1.2.0/plugin.json": {},
+		"home/a/.intermod/interbase/interbase.sh":                     {},
+	}
+	a := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-a", "DEMARCH_ROOT": "/demarch"}),
+		WithHomeDir("/home/a"),
+		WithFS(fsys),
+		WithLookPath(onPath("bd")),
+	)
+	b := New(
+		WithEnv(map[string]string{}),
+		WithHomeDir("/home/b"),
+		WithFS(fsys),
+		WithLookPath(onPath()),
+	)
+
+	if !a.HasBD() || a.HasIC() {
+		t.Errorf("a: HasBD=%v HasIC=%v, want true/false", a.HasBD(), a.HasIC())
+	}
+	if b.HasBD() {
+		t.Error("b: HasBD() = true, want false")
+	}
+	if got := a.GetBead(); got != "iv-a" {
+		t.Errorf("a.GetBead() = %q, want iv-a", got)
+	}
+	if got := b.GetBead(); got != "" {
+		t.Errorf("b.GetBead() = %q, want empty", got)
+	}
+	if !a.InEcosystem() || b.InEcosystem() {
+		t.Errorf("InEcosystem: a=%v b=%v, want true/false", a.InEcosystem(), b.InEcosystem())
+	}
+	if !a.HasCompanion("interflux") || b.HasCompanion("interflux") {
+		t.Error("HasCompanion(interflux) should only be true for a")
+	}
+	if got, want := a.PluginCachePath("interflux"), "/home/a/.claude/plugins/cache/mp/interflux/1.2.0"; got != want {
+		t.Errorf("a.PluginCachePath() = %q, want %q", got, want)
+	}
+	if got := a.EcosystemRoot(); got != "/demarch" {
+		t.Errorf("a.EcosystemRoot() = %q, want /demarch", got)
+	}
+}
+
+func TestClient_Runner(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.": {stdout: "run-1"},
+		"ic --version":               {stdout: "ic version 0.5.1"},
+	}}
+	c := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-a"}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+	)
+
+	if !c.InSprint() {
+		t.Error("InSprint() = false, want true")
+	}
+	if !c.HasICVersion("^0.5") {
+		t.Error("HasICVersion(^0.5) = false, want true")
+	}
+	want := []string{"ic run current --project=.", "ic --version"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestClient_Clock(t *testing.T) {
+	now := time.Unix(1000, 0)
+	r := &fakeRunner{results: map[string]fakeResult{"ic run current --project=.": {}}}
+	c := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-a"}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+		WithClock(func() time.Time { return now }),
+		WithProbeTTL(time.Minute),
+	)
+
+	c.InSprint()
+	now = now.Add(30 * time.Second)
+	c.InSprint()
+	if len(r.calls) != 1 {
+		t.Errorf("ic probed %d times within TTL, want 1", len(r.calls))
+	}
+	now = now.Add(time.Minute)
+	c.InSprint()
+	if len(r.calls) != 2 {
+		t.Errorf("ic probed %d times after TTL, want 2", len(r.calls))
+	}
+}
+
+func TestLookPathIn(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", "exit 0")
+	if _, err := lookPathIn("ic", "/nonexistent:"+dir); err != nil {
+		t.Errorf("lookPathIn(ic) error = %v, want found", err)
+	}
+	if _, err := lookPathIn("bd", dir); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("lookPathIn(bd) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_EnvPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", `[ "$1" = "--version" ] && echo "ic $IC_FAKE_VERSION"`)
+	c := New(WithEnv(map[string]string{"PATH": dir, "IC_FAKE_VERSION": "0.9.0"}))
+
+	if !c.HasIC() {
+		t.Fatal("HasIC() = false, want true (ic on client PATH)")
+	}
+	if got := c.ICVersion(); got != "0.9.0" {
+		t.Errorf("ICVersion() = %q, want 0.9.0 from client env", got)
+	}
+}
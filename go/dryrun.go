@@ -0,0 +1,160 @@
+package interbase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PlannedAction is a change a dry-run Client would have made.
+type PlannedAction struct {
+	Kind    string   // "exec", "write", "append", "mkdir" or "print"
+	Command []string // exec: tool name and arguments
+	Path    string   // write, append, mkdir: the file or directory; print: "stderr"
+	Data    string   // write, append, print: the content
+}
+
+// String renders the action: an exec as a shell-quoted command line that can
+// be pasted into a terminal, anything else as "KIND PATH: DATA".
+func (a PlannedAction) String() string {
+	switch a.Kind {
+	case "exec":
+		quoted := make([]string, len(a.Command))
+		for i, arg := range a.Command {
+			quoted[i] = shellQuote(arg)
+		}
+		return strings.Join(quoted, " ")
+	case "mkdir":
+		return "mkdir " + a.Path
+	default:
+		return a.Kind + " " + a.Path + ": " + strings.TrimSuffix(a.Data, "\n")
+	}
+}
+
+// dryRun is the dry-run state; nil on the Client means off.
+type dryRun struct {
+	w       io.Writer
+	mu      sync.Mutex
+	actions []PlannedAction
+}
+
+// EnableDryRun puts the default Client in dry-run mode: PhaseSet, EmitEvent,
+// NudgeCompanion and the other actions record the bd/ic commands and state
+// file writes they would perform instead of performing them. Each planned
+// action is printed to w as "[interbase] dry-run: ..." (nil w only collects
+// them; see DryRunActions). Guards and reads such as InSprint and PhaseGet
+// still run, so the plan reflects the real state.
+//
+// Setting INTERBASE_DRY_RUN=1 enables dry-run, printing to stderr, for every
+// Client created while it is set — including the default one.
+func EnableDryRun(w io.Writer) {
+	defaultClient.EnableDryRun(w)
+}
+
+// EnableDryRun is EnableDryRun for c.
+func (c *Client) EnableDryRun(w io.Writer) {
+	c.dryRun.Store(&dryRun{w: w})
+}
+
+// DisableDryRun leaves dry-run mode and forgets the collected actions.
+func DisableDryRun() {
+	defaultClient.DisableDryRun()
+}
+
+// DisableDryRun is DisableDryRun for c.
+func (c *Client) DisableDryRun() {
+	c.dryRun.Store(nil)
+}
+
+// DryRunActions returns the actions planned since dry-run was enabled,
+// oldest first. Empty when dry-run is off.
+func DryRunActions() []PlannedAction {
+	return defaultClient.DryRunActions()
+}
+
+// DryRunActions is DryRunActions for c.
+func (c *Client) DryRunActions() []PlannedAction {
+	d := c.dryRun.Load()
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]PlannedAction(nil), d.actions...)
+}
+
+// plan records a in dry-run mode and reports whether the caller must skip
+// performing it.
+func (c *Client) plan(a PlannedAction) bool {
+	d := c.dryRun.Load()
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.actions = append(d.actions, a)
+	if d.w != nil {
+		fmt.Fprintf(d.w, "[interbase] dry-run: %s\n", a)
+	}
+	return true
+}
+
+// mutate is runE for calls that change bead or event state. In dry-run mode
+// the command is recorded and reported as succeeded without running.
+func (c *Client) mutate(ctx context.Context, op, name string, args ...string) ([]byte, error) {
+	if c.plan(PlannedAction{Kind: "exec", Command: append([]string{name}, args...)}) {
+		return nil, nil
+	}
+	return c.runE(ctx, op, name, args...)
+}
+
+// writeFile writes a state file, creating its directory; in dry-run mode the
+// write is only recorded.
+func (c *Client) writeFile(path string, data []byte) error {
+	if c.plan(PlannedAction{Kind: "write", Path: path, Data: string(data)}) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// mkdir creates a directory whose existence is state (a dedup flag), failing
+// with fs.ErrExist if it is already there; its parent is created as needed.
+// In dry-run mode it is only recorded and reported as created.
+func (c *Client) mkdir(path string) error {
+	if c.plan(PlannedAction{Kind: "mkdir", Path: path}) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Mkdir(path, 0755)
+}
+
+// dryRunFromEnv reports whether INTERBASE_DRY_RUN asks for dry-run mode.
+func dryRunFromEnv(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes", "on":
+		return true
+	}
+	on, _ := strconv.ParseBool(v)
+	return on
+}
+
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell when it needs it.
+func shellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,108 @@
+package interbase
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDryRun_PhaseSet(t *testing.T) {
+	var buf bytes.Buffer
+	r := &fakeRunner{results: map[string]fakeResult{
+		`bd state iv-1 phase`:        {stdout: "planned\n"},
+		`ic run current --project=.`: {stdout: "run-1\n"},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd", "ic")), WithRunner(r), WithDryRun(&buf))
+
+	if err := c.PhaseSetE("iv-1", "executing", "plan approved"); err != nil {
+		t.Fatalf("PhaseSetE = %v, want nil", err)
+	}
+
+	for _, call := range r.calls {
+		if call != "bd state iv-1 phase" && call != "ic run current --project=." {
+			t.Errorf("dry run executed %q", call)
+		}
+	}
+	got := c.DryRunActions()
+	if len(got) != 2 {
+		t.Fatalf("DryRunActions = %v, want 2 actions", got)
+	}
+	want := []string{
+		`bd set-state iv-1 phase=executing --reason 'plan approved'`,
+		`ic events emit run-1 phase.changed '--payload={"bead":"iv-1","from":"planned","to":"executing","reason":"plan approved"}'`,
+	}
+	for i, w := range want {
+		if s := got[i].String(); s != w {
+			t.Errorf("action %d = %s, want %s", i, s, w)
+		}
+	}
+	wantOut := "[interbase] dry-run: " + want[0] + "\n[interbase] dry-run: " + want[1] + "\n"
+	if buf.String() != wantOut {
+		t.Errorf("output = %q, want %q", buf.String(), wantOut)
+	}
+}
+
+func TestDryRun_Env(t *testing.T) {
+	for v, want := range map[string]bool{"1": true, "yes": true, "true": true, "0": false, "": false} {
+		c := New(WithEnv(map[string]string{"INTERBASE_DRY_RUN": v}), WithLookPath(onPath()))
+		if got := c.dryRun.Load() != nil; got != want {
+			t.Errorf("INTERBASE_DRY_RUN=%q: dry run = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestDryRun_NudgeWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	c := New(
+		WithEnv(map[string]string{"XDG_CONFIG_HOME": dir, "CLAUDE_SESSION_ID": "s1"}),
+		WithFS(fstest.MapFS{}),
+		WithLookPath(onPath()),
+		WithDryRun(nil),
+	)
+
+	c.NudgeCompanion("interflux", "review", "clavain")
+
+	got := c.DryRunActions()
+	state := filepath.Join(dir, "interverse")
+	want := []PlannedAction{
+		{Kind: "mkdir", Path: filepath.Join(state, ".nudge-s1-clavain-interflux")},
+		{Kind: "print", Path: "stderr", Data: "[interverse] Tip: run /plugin install interflux for review.\n"},
+		{Kind: "write", Path: filepath.Join(state, "nudge-session-s1.json"), Data: `{"count":1}`},
+		{Kind: "write", Path: filepath.Join(state, "nudge-state.json"), Data: `{"clavain:interflux":{"ignores":1,"dismissed":false}}`},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DryRunActions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Kind != want[i].Kind || got[i].Path != want[i].Path || got[i].Data != want[i].Data {
+			t.Errorf("action %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if _, err := os.Stat(state); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run created %s (err %v)", state, err)
+	}
+}
+
+func TestDryRun_OutboxPlannedNotWritten(t *testing.T) {
+	dir := t.TempDir()
+	c := New(WithEnv(map[string]string{"XDG_CONFIG_HOME": dir}), WithLookPath(onPath()),
+		WithOutbox(OutboxConfig{}), WithDryRun(nil))
+
+	c.EmitEvent("run-1", "a.one", `{"event_id":"e1"}`)
+
+	got := c.DryRunActions()
+	if len(got) != 1 || got[0].Kind != "append" || got[0].Path != c.outboxPath() {
+		t.Fatalf("DryRunActions = %v, want one outbox append", got)
+	}
+	if _, err := os.Stat(c.outboxPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run wrote the outbox (err %v)", err)
+	}
+
+	c.DisableDryRun()
+	if got := c.DryRunActions(); got != nil {
+		t.Errorf("DryRunActions after DisableDryRun = %v, want nil", got)
+	}
+}
@@ -0,0 +1,154 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// DefaultEmitterQueue is the number of events an Emitter holds before Emit
+// starts dropping them.
+const DefaultEmitterQueue = 256
+
+// ErrEmitterClosed is returned by Flush after Close.
+var ErrEmitterClosed = errors.New("interbase: emitter closed")
+
+// Emitter is an asynchronous queue in front of EmitEvent: a background
+// goroutine delivers events so callers such as hooks and MCP tool handlers
+// never wait on ic. Events are delivered in the order they were queued, with
+// the same validation, envelope and outbox handling as EmitEvent; the
+// envelope is captured when the event is queued, so its timestamp, bead and
+// session are those of the Emit call. It does not reduce the number of ic
+// invocations: ic has no multi-event emit, so each event is still its own
+// `ic events emit`.
+//
+// Emit never blocks: when the queue is full, or after Close, events are
+// logged and dropped.
+type Emitter struct {
+	c     *Client
+	queue chan emitterItem
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// emitterItem is a queued event, or a Flush marker when flushed is set.
+type emitterItem struct {
+	runID     string
+	eventType string
+	payload   string
+	env       *Envelope // captured at Emit; nil when envelopes are off
+	flushed   chan struct{}
+}
+
+// NewEmitter starts an Emitter on the default Client holding up to size
+// queued events (DefaultEmitterQueue if size <= 0). Call Close when done.
+func NewEmitter(size int) *Emitter {
+	return defaultClient.NewEmitter(size)
+}
+
+// NewEmitter is NewEmitter for c.
+func (c *Client) NewEmitter(size int) *Emitter {
+	if size <= 0 {
+		size = DefaultEmitterQueue
+	}
+	e := &Emitter{
+		c:     c,
+		queue: make(chan emitterItem, size),
+		done:  make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+// Emit queues an event; the arguments are as for EmitEvent.
+func (e *Emitter) Emit(runID, eventType string, payload ...string) {
+	p := ""
+	if len(payload) > 0 {
+		p = payload[0]
+	}
+	e.enqueue(emitterItem{runID: runID, eventType: eventType, payload: p, env: e.c.captureEnvelope()})
+}
+
+// EmitJSON queues an event with payload marshaled to JSON now, so later
+// changes to payload do not leak into the event. A nil payload sends "{}".
+func (e *Emitter) EmitJSON(runID, eventType string, payload any) {
+	p := "{}"
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			e.c.log().Warn("ic events emit skipped: cannot marshal payload", "event_type", eventType, "error", err)
+			return
+		}
+		p = string(b)
+	}
+	e.enqueue(emitterItem{runID: runID, eventType: eventType, payload: p, env: e.c.captureEnvelope()})
+}
+
+func (e *Emitter) enqueue(it emitterItem) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.c.log().Warn("emitter closed, dropping event", "event_type", it.eventType)
+		return
+	}
+	select {
+	case e.queue <- it:
+	default:
+		e.c.log().Warn("emitter queue full, dropping event", "event_type", it.eventType)
+	}
+}
+
+// Flush waits until every event queued before the call has been delivered
+// (or dropped by EmitEvent's own fail-open rules). It returns ctx's error if
+// ctx is done first, and ErrEmitterClosed after Close.
+func (e *Emitter) Flush(ctx context.Context) error {
+	marker := emitterItem{flushed: make(chan struct{})}
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return ErrEmitterClosed
+	}
+	select {
+	case e.queue <- marker:
+		e.mu.RUnlock()
+	case <-ctx.Done():
+		e.mu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-marker.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events, delivers those already queued and stops the
+// worker. It is safe to call more than once.
+func (e *Emitter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	<-e.done
+	return nil
+}
+
+func (e *Emitter) loop() {
+	defer close(e.done)
+	for it := range e.queue {
+		if it.flushed != nil {
+			close(it.flushed)
+			continue
+		}
+		ctx, cancel := e.c.context()
+		err := e.c.emitEvent(ctx, it.runID, it.eventType, it.payload, it.env)
+		cancel()
+		e.c.logFailure(err, "event_type", it.eventType, "run_id", it.runID)
+	}
+}
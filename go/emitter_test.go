@@ -0,0 +1,145 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEmitter_FlushDeliversInOrder(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		`ic events emit run-1 a.one --payload={}`:      {},
+		`ic events emit run-1 a.two --payload={"n":2}`: {},
+		`ic events emit run-1 a.three --payload={}`:    {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+	e := c.NewEmitter(0)
+	defer e.Close()
+
+	e.Emit("run-1", "a.one")
+	e.EmitJSON("run-1", "a.two", map[string]int{"n": 2})
+	e.Emit("run-1", "a.three", "")
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	want := []string{
+		`ic events emit run-1 a.one --payload={}`,
+		`ic events emit run-1 a.two --payload={"n":2}`,
+		`ic events emit run-1 a.three --payload={}`,
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestEmitter_CloseDrains(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		`ic events emit run-1 a.one --payload={}`: {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+	e := c.NewEmitter(0)
+
+	e.Emit("run-1", "a.one")
+	e.Close()
+	e.Close()
+	e.Emit("run-1", "a.one") // dropped after Close
+
+	if len(r.calls) != 1 {
+		t.Errorf("runner calls = %q, want exactly one", r.calls)
+	}
+	if err := e.Flush(context.Background()); !errors.Is(err, ErrEmitterClosed) {
+		t.Errorf("Flush after Close = %v, want ErrEmitterClosed", err)
+	}
+}
+
+// blockingRunner holds every call until release is closed.
+type blockingRunner struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return nil, nil, nil
+}
+
+func TestEmitter_NeverBlocks(t *testing.T) {
+	r := &blockingRunner{started: make(chan struct{}, 8), release: make(chan struct{})}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+	e := c.NewEmitter(1)
+	defer e.Close()
+	defer close(r.release)
+
+	e.Emit("run-1", "a.one")
+	<-r.started // worker is now stuck in ic
+
+	done := make(chan struct{})
+	go func() {
+		e.Emit("run-1", "a.two")   // fills the queue
+		e.Emit("run-1", "a.three") // dropped: queue full
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Emit blocked on a full queue")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := e.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Flush with stuck ic = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestEmitter_EnvelopeCapturedAtEmit(t *testing.T) {
+	var now atomic.Int64
+	emitted := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now.Store(emitted.UnixNano())
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	rec := &RecordingRunner{Next: RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil, nil
+	})}
+	c := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-1"}),
+		WithLookPath(onPath("ic")),
+		WithRunner(rec),
+		WithEnvelope("clavain"),
+		WithClock(func() time.Time { return time.Unix(0, now.Load()).UTC() }),
+	)
+	e := c.NewEmitter(0)
+	defer e.Close()
+
+	e.Emit("run-1", "a.zero")
+	<-started // worker busy: a.one waits in the queue
+	e.Emit("run-1", "a.one")
+	now.Store(emitted.Add(time.Hour).UnixNano()) // delivery happens later
+	close(release)
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	calls := rec.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("runner calls = %q, want two emits", rec.Commands())
+	}
+	var p struct {
+		Envelope Envelope `json:"_envelope"`
+	}
+	arg := calls[1].Args[len(calls[1].Args)-1]
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(arg, "--payload=")), &p); err != nil {
+		t.Fatalf("payload %s: %v", arg, err)
+	}
+	if !p.Envelope.Timestamp.Equal(emitted) || p.Envelope.Bead != "iv-1" {
+		t.Errorf("envelope = %+v, want ts %v and bead iv-1 from the Emit call", p.Envelope, emitted)
+	}
+}
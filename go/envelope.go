@@ -0,0 +1,106 @@
+package interbase
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// SDKVersion is the interbase SDK version reported in event envelopes.
+// Kept in step with lib/VERSION.
+const SDKVersion = "2.0.0"
+
+// envelopeKey is the payload field that carries the envelope. The leading
+// underscore keeps it clear of plugin-defined fields.
+const envelopeKey = "_envelope"
+
+// Envelope is the correlation metadata added to event payloads when the
+// envelope is enabled, so intercore consumers can attribute events without
+// every plugin reimplementing it.
+type Envelope struct {
+	Bead       string    `json:"bead,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Plugin     string    `json:"plugin,omitempty"`
+	SDKVersion string    `json:"sdk_version"`
+	Timestamp  time.Time `json:"ts"`
+	Host       string    `json:"host,omitempty"`
+}
+
+// envelopeConfig is the opt-in state; nil means envelopes are off.
+type envelopeConfig struct {
+	plugin string
+}
+
+// EnableEnvelope turns on the event envelope for EmitEvent and friends,
+// attributing events to plugin. Off by default.
+func EnableEnvelope(plugin string) {
+	defaultClient.EnableEnvelope(plugin)
+}
+
+// EnableEnvelope is EnableEnvelope for c.
+func (c *Client) EnableEnvelope(plugin string) {
+	c.envelope.Store(&envelopeConfig{plugin: plugin})
+}
+
+// DisableEnvelope turns the event envelope back off.
+func DisableEnvelope() {
+	defaultClient.DisableEnvelope()
+}
+
+// DisableEnvelope is DisableEnvelope for c.
+func (c *Client) DisableEnvelope() {
+	c.envelope.Store(nil)
+}
+
+// envelopeFor builds the envelope for an event emitted now.
+func (c *Client) envelopeFor(cfg *envelopeConfig) Envelope {
+	host, _ := os.Hostname()
+	return Envelope{
+		Bead:       c.GetBead(),
+		SessionID:  c.getenv("CLAUDE_SESSION_ID"),
+		Plugin:     cfg.plugin,
+		SDKVersion: SDKVersion,
+		Timestamp:  c.now().UTC(),
+		Host:       host,
+	}
+}
+
+// captureEnvelope builds the envelope for an event emitted now, or returns
+// nil when envelopes are off.
+func (c *Client) captureEnvelope() *Envelope {
+	cfg := c.envelope.Load()
+	if cfg == nil {
+		return nil
+	}
+	env := c.envelopeFor(cfg)
+	return &env
+}
+
+// wrapEnvelope adds env, or the envelope for an event emitted now when env
+// is nil and envelopes are enabled, to a JSON object payload. Non-object
+// payloads, and payloads that already carry an envelope, are returned
+// unchanged.
+func (c *Client) wrapEnvelope(payload string, env *Envelope) string {
+	if env == nil {
+		if env = c.captureEnvelope(); env == nil {
+			return payload
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil || fields == nil {
+		return payload
+	}
+	if _, ok := fields[envelopeKey]; ok {
+		return payload
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return payload
+	}
+	fields[envelopeKey] = raw
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return string(out)
+}
@@ -0,0 +1,69 @@
+package interbase
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// emittedPayload returns the --payload value of the only recorded call.
+func emittedPayload(t *testing.T, r *fakeRunner) string {
+	t.Helper()
+	if len(r.calls) != 1 {
+		t.Fatalf("runner calls = %q, want exactly one emit", r.calls)
+	}
+	_, p, ok := strings.Cut(r.calls[0], "--payload=")
+	if !ok {
+		t.Fatalf("call %q has no --payload", r.calls[0])
+	}
+	return p
+}
+
+func TestEnvelope_AddsCorrelationFields(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &fakeRunner{}
+	c := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-1", "CLAUDE_SESSION_ID": "sess-9"}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+		WithClock(func() time.Time { return now }),
+		WithEnvelope("interflux"),
+	)
+
+	c.EmitEvent("run-1", "review.completed", `{"score":4}`)
+
+	var got struct {
+		Score    int      `json:"score"`
+		Envelope Envelope `json:"_envelope"`
+	}
+	if err := json.Unmarshal([]byte(emittedPayload(t, r)), &got); err != nil {
+		t.Fatalf("payload not JSON: %v", err)
+	}
+	host, _ := os.Hostname()
+	want := Envelope{Bead: "iv-1", SessionID: "sess-9", Plugin: "interflux", SDKVersion: SDKVersion, Timestamp: now, Host: host}
+	if got.Score != 4 || got.Envelope != want {
+		t.Errorf("payload = %+v, want score 4 and envelope %+v", got, want)
+	}
+}
+
+func TestEnvelope_OffByDefault(t *testing.T) {
+	r := &fakeRunner{}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+
+	c.EmitEvent("run-1", "hook.fired", `{"a":1}`)
+	if got := emittedPayload(t, r); got != `{"a":1}` {
+		t.Errorf("payload = %s, want unchanged", got)
+	}
+}
+
+func TestEnvelope_NonObjectUnchanged(t *testing.T) {
+	r := &fakeRunner{}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r), WithEnvelope("p"))
+
+	c.EmitEvent("run-1", "hook.fired", `[1,2]`)
+	if got := emittedPayload(t, r); got != `[1,2]` {
+		t.Errorf("payload = %s, want array unchanged", got)
+	}
+}
@@ -0,0 +1,121 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/mistakeknot/interbase/go/toolerror"
+)
+
+// Error classes reported by the ...E action variants (PhaseSetE, EmitEventE,
+// ...). Test with errors.Is; subprocess failures are also a *CommandError.
+var (
+	// ErrNotAvailable means the action could not run: the tool it needs is
+	// not on PATH, or EmitEventE was given no run ID and no run is active.
+	ErrNotAvailable = errors.New("not available")
+
+	// ErrTimeout means the subprocess was killed at the context deadline.
+	ErrTimeout = errors.New("timed out")
+
+	// ErrCircuitOpen means the call was skipped because the tool's circuit
+	// breaker is open (see EnableBreaker). Such errors are ErrNotAvailable too.
+	ErrCircuitOpen = errors.New("circuit open")
+
+	// ErrTransient means the failure is classified toolerror.ErrTransient
+	// (see CommandError.Type): the same call may succeed if retried.
+	ErrTransient = errors.New("transient failure")
+)
+
+// CommandError is an ic or bd invocation that failed.
+type CommandError struct {
+	Op       string   // short command name, e.g. "bd set-state"
+	Args     []string // full command line, tool name first
+	ExitCode int      // -1 if the process did not exit on its own
+	Stderr   []byte   // captured stderr
+	Type     string   // toolerror class: ErrTransient, ErrNotFound or ErrInternal
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s failed: %v", e.Op, e.Err)
+	if s := strings.TrimSpace(string(e.Stderr)); s != "" {
+		msg += ": " + s
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error { return e.Err }
+
+// Is classifies e as ErrTimeout when the deadline killed it, as
+// ErrNotAvailable when the tool vanished from PATH after the guard passed,
+// and as ErrTransient when Type is toolerror.ErrTransient.
+func (e *CommandError) Is(target error) bool {
+	switch target {
+	case ErrTransient:
+		return e.Type == toolerror.ErrTransient
+	case ErrTimeout:
+		return errors.Is(e.Err, context.DeadlineExceeded)
+	case ErrNotAvailable:
+		return errors.Is(e.Err, exec.ErrNotFound)
+	}
+	return false
+}
+
+// notAvailable reports that tool is missing.
+func notAvailable(tool string) error {
+	return fmt.Errorf("%s %w", tool, ErrNotAvailable)
+}
+
+// runE is run for actions: a failure comes back as a classified
+// *CommandError named op, carrying the exit code and stderr. Runner errors
+// that expose ExitCode() (as *exec.ExitError does) supply the exit code.
+// Transient failures are retried under the client's RetryPolicy, if enabled;
+// the circuit breaker sees only the outcome after the last attempt.
+func (c *Client) runE(ctx context.Context, op, name string, args ...string) ([]byte, error) {
+	stdout, err := c.runRetry(ctx, op, name, args...)
+	c.observe(name, err)
+	return stdout, err
+}
+
+func (c *Client) runRetry(ctx context.Context, op, name string, args ...string) ([]byte, error) {
+	policy, retry := c.retryPolicy()
+	for attempt := 1; ; attempt++ {
+		stdout, err := c.runOnce(ctx, policy, op, name, args...)
+		if err == nil || !retry || attempt >= policy.MaxAttempts || !errors.Is(err, ErrTransient) || ctx.Err() != nil {
+			return stdout, err
+		}
+		delay := policy.backoff(attempt)
+		c.log().Debug(op+" failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		if !sleepCtx(ctx, delay) {
+			return stdout, err
+		}
+	}
+}
+
+// runOnce is one attempt of runE.
+func (c *Client) runOnce(ctx context.Context, policy RetryPolicy, op, name string, args ...string) ([]byte, error) {
+	stdout, stderr, err := c.run(ctx, name, args...)
+	if err == nil {
+		return stdout, nil
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return nil, err
+	}
+	code := -1
+	var exit interface{ ExitCode() int }
+	if errors.As(err, &exit) && ctx.Err() == nil {
+		code = exit.ExitCode()
+	}
+	ce := &CommandError{
+		Op:       op,
+		Args:     append([]string{name}, args...),
+		ExitCode: code,
+		Stderr:   stderr,
+		Err:      err,
+	}
+	ce.Type = policy.classify(ce)
+	return stdout, ce
+}
@@ -0,0 +1,98 @@
+package interbase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/interbase/go/events"
+)
+
+func TestPhaseSetE_NotAvailable(t *testing.T) {
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath()), WithRunner(&fakeRunner{}))
+	if err := c.PhaseSetE("iv-1", "executing"); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("PhaseSetE without bd = %v, want ErrNotAvailable", err)
+	}
+	if err := c.EmitEventE("run-1", "a.one"); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("EmitEventE without ic = %v, want ErrNotAvailable", err)
+	}
+}
+
+func TestPhaseSetE_CommandError(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "bd", `echo "database is locked" >&2; exit 3`)
+	c := New(WithEnv(map[string]string{"PATH": dir}))
+
+	err := c.PhaseSetE("iv-1", "executing")
+	var ce *CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("PhaseSetE = %v, want *CommandError", err)
+	}
+	if ce.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", ce.ExitCode)
+	}
+	if got := strings.TrimSpace(string(ce.Stderr)); got != "database is locked" {
+		t.Errorf("Stderr = %q, want %q", got, "database is locked")
+	}
+	if want := "bd set-state failed: exit status 3: database is locked"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if got := strings.Join(ce.Args, " "); got != "bd set-state iv-1 phase=executing" {
+		t.Errorf("Args = %q", got)
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, ErrNotAvailable) {
+		t.Errorf("exit failure misclassified: %v", err)
+	}
+}
+
+func TestPhaseSetE_Timeout(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "bd", hangScript(t))
+	c := New(WithEnv(map[string]string{"PATH": dir}), WithTimeout(100*time.Millisecond))
+
+	err := c.PhaseSetE("iv-1", "executing")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("PhaseSetE = %v, want ErrTimeout", err)
+	}
+	var ce *CommandError
+	if errors.As(err, &ce) && ce.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1 for a killed process", ce.ExitCode)
+	}
+}
+
+func TestEmitEventE(t *testing.T) {
+	reg := events.NewRegistry()
+	reg.MustRegister("a.strict", `{"required": ["bead"]}`)
+	r := &fakeRunner{results: map[string]fakeResult{
+		`ic run current --project=.`:              {err: errors.New("exit status 1")},
+		`ic events emit run-1 a.one --payload={}`: {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r), WithEventRegistry(reg))
+
+	if err := c.EmitEventE("run-1", "a.one"); err != nil {
+		t.Errorf("EmitEventE = %v, want nil", err)
+	}
+	if err := c.EmitEventE("", "a.one"); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("EmitEventE with no active run = %v, want ErrNotAvailable", err)
+	}
+	if err := c.EmitEventE("run-1", "a.one", `{"broken":`); err == nil || errors.Is(err, ErrNotAvailable) {
+		t.Errorf("EmitEventE(invalid JSON) = %v, want a payload error", err)
+	}
+	var ve *events.ValidationError
+	if err := c.EmitEventE("run-1", "a.strict", `{}`); !errors.As(err, &ve) {
+		t.Errorf("EmitEventE(schema mismatch) = %v, want *events.ValidationError", err)
+	}
+	var ce *CommandError
+	if err := c.EmitEventE("run-1", "a.two"); !errors.As(err, &ce) || ce.Op != "ic events emit" {
+		t.Errorf("EmitEventE(ic failure) = %v, want *CommandError for ic events emit", err)
+	}
+}
+
+func TestPhaseTransitionE_Refused(t *testing.T) {
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(&fakeRunner{}))
+	err := c.PhaseTransitionE("iv-1", PhaseBrainstorm, PhaseDone)
+	if err == nil || !strings.Contains(err.Error(), "illegal transition") {
+		t.Errorf("PhaseTransitionE = %v, want illegal transition", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Package events is the registry of Demarch event types and their payload
+// schemas.
+//
+// Plugins register the event types they emit, each with a JSON Schema for its
+// payload, usually from an init function:
+//
+//	func init() {
+//		events.MustRegister("review.completed", `{
+//			"type": "object",
+//			"required": ["bead", "verdict"],
+//			"properties": {
+//				"bead":    {"type": "string", "minLength": 1},
+//				"verdict": {"enum": ["approve", "reject"]}
+//			}
+//		}`)
+//	}
+//
+// interbase.EmitEvent validates payloads against the registered schema before
+// starting ic; a payload that does not match is logged and dropped. Event
+// types with no registered schema are not checked.
+//
+// Schemas use a subset of JSON Schema (draft 2020-12): type, enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf, oneOf and not. Annotations ($schema, $id,
+// title, description, ...) are ignored; any other keyword is rejected at
+// registration so a schema never silently checks less than it says.
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+var typeRe = regexp.MustCompile(`^[a-z][a-z0-9_-]*(\.[a-z][a-z0-9_-]*)*$`)
+
+// ValidType reports whether eventType is a well-formed event type name:
+// lowercase dot-separated segments such as "phase.changed".
+func ValidType(eventType string) bool {
+	return typeRe.MatchString(eventType)
+}
+
+// Registry maps event types to payload schemas. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]*Schema
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]*Schema)}
+}
+
+// Default is the process-wide registry used by the package-level functions
+// and by interbase Clients unless they are given another one.
+var Default = NewRegistry()
+
+// Register adds eventType with the JSON Schema schema to the Default registry.
+func Register(eventType, schema string) error {
+	return Default.Register(eventType, schema)
+}
+
+// MustRegister is Register that panics on error, for use in init functions.
+func MustRegister(eventType, schema string) {
+	Default.MustRegister(eventType, schema)
+}
+
+// Validate checks payload against eventType's schema in the Default registry.
+func Validate(eventType string, payload []byte) error {
+	return Default.Validate(eventType, payload)
+}
+
+// Register adds eventType with the JSON Schema schema. It fails if the type
+// name is malformed, the schema does not compile, or the type is already
+// registered: two plugins disagreeing about a payload is a bug to surface,
+// not to resolve by load order.
+func (r *Registry) Register(eventType, schema string) error {
+	if !ValidType(eventType) {
+		return fmt.Errorf("events: invalid event type %q", eventType)
+	}
+	s, err := Compile([]byte(schema))
+	if err != nil {
+		return fmt.Errorf("events: %s: %w", eventType, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schemas[eventType]; ok {
+		return fmt.Errorf("events: %s already registered", eventType)
+	}
+	r.schemas[eventType] = s
+	return nil
+}
+
+// MustRegister is Register that panics on error.
+func (r *Registry) MustRegister(eventType, schema string) {
+	if err := r.Register(eventType, schema); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the schema registered for eventType.
+func (r *Registry) Lookup(eventType string) (*Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[eventType]
+	return s, ok
+}
+
+// Types returns the registered event types, sorted.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.schemas))
+	for t := range r.schemas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate checks payload against eventType's schema. Unregistered event
+// types always pass. The returned error is a *ValidationError when payload
+// does not match, or a JSON syntax error when it is not JSON at all.
+func (r *Registry) Validate(eventType string, payload []byte) error {
+	s, ok := r.Lookup(eventType)
+	if !ok {
+		return nil
+	}
+	if err := s.Validate(payload); err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			ve.EventType = eventType
+		}
+		return err
+	}
+	return nil
+}
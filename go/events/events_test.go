@@ -0,0 +1,153 @@
+package events_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mistakeknot/interbase/go/events"
+)
+
+const reviewSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "review.completed",
+	"type": "object",
+	"required": ["bead", "verdict"],
+	"additionalProperties": false,
+	"properties": {
+		"bead":     {"type": "string", "pattern": "^[a-z]+-[a-z0-9]+$"},
+		"verdict":  {"enum": ["approve", "reject"]},
+		"score":    {"type": "integer", "minimum": 0, "maximum": 5},
+		"findings": {"type": "array", "maxItems": 2, "items": {"type": "string", "minLength": 1}}
+	}
+}`
+
+func TestRegister(t *testing.T) {
+	r := events.NewRegistry()
+	if err := r.Register("review.completed", reviewSchema); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := r.Register("review.completed", `{}`); err == nil {
+		t.Error("duplicate Register succeeded, want error")
+	}
+	if err := r.Register("Review Completed", `{}`); err == nil {
+		t.Error("Register accepted malformed event type")
+	}
+	if err := r.Register("a.b", `{"$ref": "#/defs/x"}`); err == nil {
+		t.Error("Register accepted unsupported keyword $ref")
+	}
+	if err := r.Register("a.c", `{"type": "strnig"}`); err == nil {
+		t.Error("Register accepted unknown type name")
+	}
+	if err := r.Register("a.d", `not json`); err == nil {
+		t.Error("Register accepted invalid JSON")
+	}
+	if got, want := r.Types(), []string{"review.completed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() = %q, want %q", got, want)
+	}
+}
+
+func TestMustRegister_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister did not panic on a bad schema")
+		}
+	}()
+	events.NewRegistry().MustRegister("x.y", `[]`)
+}
+
+func TestValidate(t *testing.T) {
+	r := events.NewRegistry()
+	r.MustRegister("review.completed", reviewSchema)
+
+	tests := []struct {
+		payload string
+		path    string // "" means valid
+		reason  string
+	}{
+		{`{"bead":"iv-1","verdict":"approve"}`, "", ""},
+		{`{"bead":"iv-1","verdict":"reject","score":5,"findings":["a","b"]}`, "", ""},
+		{`{"bead":"iv-1","verdict":"approve","score":5.0}`, "", ""},
+		{`{"verdict":"approve"}`, "/", `missing required property "bead"`},
+		{`{"bead":"iv-1","verdict":"maybe"}`, "/verdict", "must be one of"},
+		{`{"bead":"IV 1","verdict":"approve"}`, "/bead", "must match"},
+		{`{"bead":"iv-1","verdict":"approve","score":6}`, "/score", "must be <= 5"},
+		{`{"bead":"iv-1","verdict":"approve","score":2.5}`, "/score", "got number, want integer"},
+		{`{"bead":"iv-1","verdict":"approve","findings":["a",""]}`, "/findings/1", "at least 1"},
+		{`{"bead":"iv-1","verdict":"approve","findings":["a","b","c"]}`, "/findings", "at most 2"},
+		{`{"bead":"iv-1","verdict":"approve","extra":true}`, "/", `unexpected property "extra"`},
+		{`[]`, "/", "got array, want object"},
+	}
+	for _, tt := range tests {
+		err := r.Validate("review.completed", []byte(tt.payload))
+		if tt.path == "" {
+			if err != nil {
+				t.Errorf("Validate(%s) = %v, want nil", tt.payload, err)
+			}
+			continue
+		}
+		var ve *events.ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("Validate(%s) = %v, want *ValidationError", tt.payload, err)
+			continue
+		}
+		if ve.EventType != "review.completed" {
+			t.Errorf("EventType = %q, want review.completed", ve.EventType)
+		}
+		if path := ve.Path; path != strings.TrimSuffix(tt.path, "/") {
+			t.Errorf("Validate(%s) path = %q, want %q", tt.payload, path, tt.path)
+		}
+		if !strings.Contains(ve.Reason, tt.reason) {
+			t.Errorf("Validate(%s) reason = %q, want it to contain %q", tt.payload, ve.Reason, tt.reason)
+		}
+	}
+}
+
+func TestValidate_Unregistered(t *testing.T) {
+	r := events.NewRegistry()
+	if err := r.Validate("never.registered", []byte(`{"anything":1}`)); err != nil {
+		t.Errorf("Validate(unregistered) = %v, want nil", err)
+	}
+}
+
+func TestValidate_Combinators(t *testing.T) {
+	s, err := events.Compile([]byte(`{
+		"anyOf": [{"type": "string"}, {"type": "null"}],
+		"not": {"const": "forbidden"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ok := range []string{`"x"`, `null`} {
+		if err := s.Validate([]byte(ok)); err != nil {
+			t.Errorf("Validate(%s) = %v, want nil", ok, err)
+		}
+	}
+	for _, bad := range []string{`1`, `"forbidden"`} {
+		if err := s.Validate([]byte(bad)); err == nil {
+			t.Errorf("Validate(%s) = nil, want error", bad)
+		}
+	}
+
+	one, err := events.Compile([]byte(`{"oneOf": [{"type": "integer"}, {"minimum": 10}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := one.Validate([]byte(`3`)); err != nil {
+		t.Errorf("oneOf Validate(3) = %v, want nil", err)
+	}
+	if err := one.Validate([]byte(`12`)); err == nil {
+		t.Error("oneOf Validate(12) = nil, want error (matches both)")
+	}
+}
+
+func TestValidationError_Message(t *testing.T) {
+	r := events.NewRegistry()
+	r.MustRegister("phase.changed", `{"required": ["to"]}`)
+	err := r.Validate("phase.changed", []byte(`{}`))
+	want := `phase.changed payload /: missing required property "to"`
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,507 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled payload schema.
+type Schema struct {
+	root *node
+}
+
+// ValidationError describes the first place a payload departs from its schema.
+type ValidationError struct {
+	EventType string // set by Registry.Validate
+	Path      string // JSON Pointer to the offending value; "" is the root
+	Reason    string
+}
+
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	if e.EventType == "" {
+		return fmt.Sprintf("payload %s: %s", path, e.Reason)
+	}
+	return fmt.Sprintf("%s payload %s: %s", e.EventType, path, e.Reason)
+}
+
+// Compile parses a JSON Schema. See the package doc for supported keywords.
+func Compile(schema []byte) (*Schema, error) {
+	v, err := decode(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	root, err := compileNode(v, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// Validate checks payload against s.
+func (s *Schema) Validate(payload []byte) error {
+	v, err := decode(payload)
+	if err != nil {
+		return err
+	}
+	if ve := s.root.check(v, ""); ve != nil {
+		return ve
+	}
+	return nil
+}
+
+// decode parses JSON keeping numbers exact, so "integer" means integer.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// node is one compiled (sub)schema. A nil *float64 or *int bound is unset.
+type node struct {
+	never bool // the schema `false`
+
+	types    []string
+	enum     []any
+	hasConst bool
+	constVal any
+
+	properties   map[string]*node
+	required     []string
+	additional   *node // nil allows anything
+	items        *node
+	minItems     *int
+	maxItems     *int
+	minLength    *int
+	maxLength    *int
+	pattern      *regexp.Regexp
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin *float64
+	exclusiveMax *float64
+	allOf, anyOf []*node
+	oneOf        []*node
+	not          *node
+}
+
+// annotations carry no validation meaning and are accepted anywhere.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true, "format": true,
+}
+
+var jsonTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+func compileNode(v any, path string) (*node, error) {
+	switch s := v.(type) {
+	case bool:
+		return &node{never: !s}, nil
+	case map[string]any:
+		n := &node{}
+		keys := make([]string, 0, len(s))
+		for k := range s {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := n.set(k, s[k], path+"/"+k); err != nil {
+				return nil, err
+			}
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("schema %s: must be an object or boolean", orRoot(path))
+	}
+}
+
+func (n *node) set(key string, v any, path string) error {
+	bad := func(want string) error {
+		return fmt.Errorf("schema %s: must be %s", path, want)
+	}
+	var err error
+	switch key {
+	case "type":
+		switch t := v.(type) {
+		case string:
+			n.types = []string{t}
+		case []any:
+			for _, e := range t {
+				s, ok := e.(string)
+				if !ok {
+					return bad("a type name or array of type names")
+				}
+				n.types = append(n.types, s)
+			}
+		default:
+			return bad("a type name or array of type names")
+		}
+		for _, t := range n.types {
+			if !jsonTypes[t] {
+				return fmt.Errorf("schema %s: unknown type %q", path, t)
+			}
+		}
+	case "enum":
+		list, ok := v.([]any)
+		if !ok {
+			return bad("an array")
+		}
+		n.enum = list
+	case "const":
+		n.hasConst, n.constVal = true, v
+	case "properties":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return bad("an object")
+		}
+		n.properties = make(map[string]*node, len(m))
+		for name, sub := range m {
+			if n.properties[name], err = compileNode(sub, path+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	case "required":
+		list, ok := v.([]any)
+		if !ok {
+			return bad("an array of strings")
+		}
+		for _, e := range list {
+			s, ok := e.(string)
+			if !ok {
+				return bad("an array of strings")
+			}
+			n.required = append(n.required, s)
+		}
+	case "additionalProperties":
+		n.additional, err = compileNode(v, path)
+	case "items":
+		n.items, err = compileNode(v, path)
+	case "allOf", "anyOf", "oneOf":
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			return bad("a non-empty array of schemas")
+		}
+		subs := make([]*node, len(list))
+		for i, sub := range list {
+			if subs[i], err = compileNode(sub, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		switch key {
+		case "allOf":
+			n.allOf = subs
+		case "anyOf":
+			n.anyOf = subs
+		default:
+			n.oneOf = subs
+		}
+	case "not":
+		n.not, err = compileNode(v, path)
+	case "pattern":
+		s, ok := v.(string)
+		if !ok {
+			return bad("a string")
+		}
+		if n.pattern, err = regexp.Compile(s); err != nil {
+			return fmt.Errorf("schema %s: %w", path, err)
+		}
+	case "minItems", "maxItems", "minLength", "maxLength":
+		i, ok := count(v)
+		if !ok {
+			return bad("a non-negative integer")
+		}
+		switch key {
+		case "minItems":
+			n.minItems = &i
+		case "maxItems":
+			n.maxItems = &i
+		case "minLength":
+			n.minLength = &i
+		default:
+			n.maxLength = &i
+		}
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+		f, ok := number(v)
+		if !ok {
+			return bad("a number")
+		}
+		switch key {
+		case "minimum":
+			n.minimum = &f
+		case "maximum":
+			n.maximum = &f
+		case "exclusiveMinimum":
+			n.exclusiveMin = &f
+		default:
+			n.exclusiveMax = &f
+		}
+	default:
+		if !annotations[key] {
+			return fmt.Errorf("schema %s: unsupported keyword %q", orRoot(strings.TrimSuffix(path, "/"+key)), key)
+		}
+	}
+	return err
+}
+
+func (n *node) check(v any, path string) *ValidationError {
+	fail := func(format string, args ...any) *ValidationError {
+		return &ValidationError{Path: path, Reason: fmt.Sprintf(format, args...)}
+	}
+	if n.never {
+		return fail("no value is allowed here")
+	}
+	if len(n.types) > 0 && !hasType(v, n.types) {
+		return fail("got %s, want %s", typeOf(v), strings.Join(n.types, " or "))
+	}
+	if n.hasConst && !equal(v, n.constVal) {
+		return fail("must be %s", render(n.constVal))
+	}
+	if n.enum != nil && !inEnum(v, n.enum) {
+		opts := make([]string, len(n.enum))
+		for i, e := range n.enum {
+			opts[i] = render(e)
+		}
+		return fail("must be one of %s", strings.Join(opts, ", "))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, name := range n.required {
+			if _, ok := val[name]; !ok {
+				return fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, declared := n.properties[name]
+			if !declared {
+				sub = n.additional
+			}
+			if sub == nil {
+				continue
+			}
+			if !declared && sub.never {
+				return fail("unexpected property %q", name)
+			}
+			if ve := sub.check(val[name], path+"/"+escape(name)); ve != nil {
+				return ve
+			}
+		}
+	case []any:
+		if n.minItems != nil && len(val) < *n.minItems {
+			return fail("must have at least %d items", *n.minItems)
+		}
+		if n.maxItems != nil && len(val) > *n.maxItems {
+			return fail("must have at most %d items", *n.maxItems)
+		}
+		if n.items != nil {
+			for i, e := range val {
+				if ve := n.items.check(e, path+"/"+strconv.Itoa(i)); ve != nil {
+					return ve
+				}
+			}
+		}
+	case string:
+		l := utf8.RuneCountInString(val)
+		if n.minLength != nil && l < *n.minLength {
+			return fail("must be at least %d characters", *n.minLength)
+		}
+		if n.maxLength != nil && l > *n.maxLength {
+			return fail("must be at most %d characters", *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(val) {
+			return fail("must match %q", n.pattern.String())
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if n.minimum != nil && f < *n.minimum {
+			return fail("must be >= %v", *n.minimum)
+		}
+		if n.maximum != nil && f > *n.maximum {
+			return fail("must be <= %v", *n.maximum)
+		}
+		if n.exclusiveMin != nil && f <= *n.exclusiveMin {
+			return fail("must be > %v", *n.exclusiveMin)
+		}
+		if n.exclusiveMax != nil && f >= *n.exclusiveMax {
+			return fail("must be < %v", *n.exclusiveMax)
+		}
+	}
+
+	for _, sub := range n.allOf {
+		if ve := sub.check(v, path); ve != nil {
+			return ve
+		}
+	}
+	if n.anyOf != nil {
+		ok := false
+		for _, sub := range n.anyOf {
+			if sub.check(v, path) == nil {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fail("matches none of anyOf")
+		}
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, sub := range n.oneOf {
+			if sub.check(v, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fail("matches %d of oneOf, want exactly 1", matched)
+		}
+	}
+	if n.not != nil && n.not.check(v, path) == nil {
+		return fail("must not match the schema under \"not\"")
+	}
+	return nil
+}
+
+func hasType(v any, types []string) bool {
+	got := typeOf(v)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		if equal(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal is JSON value equality: numbers compare by value, so 1 equals 1.0.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !equal(xv, yv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func number(v any) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func count(v any) (int, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := n.Int64()
+	if err != nil || i < 0 || i > math.MaxInt32 {
+		return 0, false
+	}
+	return int(i), true
+}
+
+func render(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// escape encodes a property name as a JSON Pointer reference token.
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,48 @@
+package interbase
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// DefaultTimeout bounds every ic/bd subprocess started by the context-free
+// guard and action functions. A hung CLI must never hang the calling hook.
+const DefaultTimeout = 5 * time.Second
+
+// SetTimeout sets the deadline applied by the context-free wrappers
+// (InSprint, PhaseSet, EmitEvent, ...). A timeout of zero or less disables it.
+func SetTimeout(d time.Duration) {
+	defaultClient.SetTimeout(d)
+}
+
+// SetTimeout is SetTimeout for c.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.timeout.Store(int64(d))
+}
+
+// context returns a context bounded by c's timeout.
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	d := time.Duration(c.timeout.Load())
+	if d <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// command builds an exec.Cmd that is killed when ctx is done. WaitDelay stops
+// a grandchild holding our pipes open from outliving the deadline.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = 500 * time.Millisecond
+	return cmd
+}
+
+// runErr prefers the context error over the "signal: killed" that a
+// deadline-cancelled subprocess reports, so logs say what actually happened.
+func runErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package interbase
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// hangScript returns a fake-binary body that blocks well past any test timeout.
+// sleep is resolved before tests narrow PATH to the fake directory.
+func hangScript(t *testing.T) string {
+	t.Helper()
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not on PATH")
+	}
+	return "exec " + sleep + " 5"
+}
+
+func TestInSprint_TimesOut(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	SetTimeout(100 * time.Millisecond)
+	t.Cleanup(func() { SetTimeout(DefaultTimeout) })
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", hangScript(t))
+	t.Setenv("PATH", dir)
+	t.Setenv("CLAVAIN_BEAD_ID", "iv-test")
+
+	start := time.Now()
+	if InSprint() {
+		t.Error("InSprint() = true for hung ic, want false (fail-open)")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("InSprint() took %s, want bounded by timeout", elapsed)
+	}
+}
+
+func TestEmitEventContext_Cancelled(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", hangScript(t))
+	t.Setenv("PATH", dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	EmitEventContext(ctx, "run-123", "test-event")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("EmitEventContext took %s, want bounded by ctx", elapsed)
+	}
+}
+
+func TestSessionStatusContext_TimeoutNotCached(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", hangScript(t))
+	t.Setenv("PATH", dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	SessionStatusContext(ctx)
+
+	writeFakeBin(t, dir, "ic", "exit 0")
+	if got := SessionStatus(); got != "[interverse] beads=not-detected | ic=active" {
+		t.Errorf("SessionStatus() = %q, want ic=active after timed-out probe", got)
+	}
+}
@@ -3,13 +3,18 @@
 // All guard functions are fail-open: they return false when their dependency
 // is missing. All action functions are silent no-ops when dependencies are
 // absent. This ensures plugins work in both standalone and ecosystem modes.
+//
+// The package-level functions use a default Client bound to the process
+// environment, filesystem and PATH. Use New to build isolated Clients.
 package interbase
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -18,114 +23,366 @@ import (
 // --- Guards ---
 
 // HasIC returns true if the ic (Intercore) CLI is on PATH.
+// The lookup is cached; see SetProbeTTL and Refresh.
 func HasIC() bool {
-	_, err := exec.LookPath("ic")
-	return err == nil
+	return defaultClient.HasIC()
+}
+
+// HasIC is HasIC for c.
+func (c *Client) HasIC() bool {
+	return c.hasBinary("ic")
 }
 
 // HasBD returns true if the bd (Beads) CLI is on PATH.
+// The lookup is cached; see SetProbeTTL and Refresh.
 func HasBD() bool {
-	_, err := exec.LookPath("bd")
-	return err == nil
+	return defaultClient.HasBD()
+}
+
+// HasBD is HasBD for c.
+func (c *Client) HasBD() bool {
+	return c.hasBinary("bd")
 }
 
 // HasCompanion returns true if the named plugin is in the Claude Code cache.
 func HasCompanion(name string) bool {
+	return defaultClient.HasCompanion(name)
+}
+
+// HasCompanion is HasCompanion for c.
+func (c *Client) HasCompanion(name string) bool {
 	if name == "" {
 		return false
 	}
-	home, err := os.UserHomeDir()
-	if err != nil {
+	root := c.pluginCacheDir()
+	if root == "" {
 		return false
 	}
-	pattern := filepath.Join(home, ".claude", "plugins", "cache", "*", name, "*")
-	matches, err := filepath.Glob(pattern)
+	matches, err := c.glob(filepath.Join(root, "*", name, "*"))
 	return err == nil && len(matches) > 0
 }
 
 // InEcosystem returns true if the centralized interbase install exists.
 func InEcosystem() bool {
-	path := os.Getenv("INTERMOD_LIB")
+	return defaultClient.InEcosystem()
+}
+
+// InEcosystem is InEcosystem for c.
+func (c *Client) InEcosystem() bool {
+	path := c.getenv("INTERMOD_LIB")
 	if path == "" {
-		home, err := os.UserHomeDir()
+		home, err := c.homeDir()
 		if err != nil {
 			return false
 		}
 		path = filepath.Join(home, ".intermod", "interbase", "interbase.sh")
 	}
-	_, err := os.Stat(path)
+	_, err := c.stat(path)
 	return err == nil
 }
 
 // GetBead returns the current bead ID from $CLAVAIN_BEAD_ID, or empty string.
 func GetBead() string {
-	return os.Getenv("CLAVAIN_BEAD_ID")
+	return defaultClient.GetBead()
+}
+
+// GetBead is GetBead for c.
+func (c *Client) GetBead() string {
+	return c.getenv("CLAVAIN_BEAD_ID")
 }
 
 // InSprint returns true if there is an active sprint context (bead + ic run).
+// The ic run probe is cached; see SetProbeTTL and Refresh.
 func InSprint() bool {
-	if GetBead() == "" {
+	return defaultClient.InSprint()
+}
+
+// InSprint is InSprint for c.
+func (c *Client) InSprint() bool {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.InSprintContext(ctx)
+}
+
+// InSprintContext is InSprint bounded by ctx. Returns false if ctx expires.
+func InSprintContext(ctx context.Context) bool {
+	return defaultClient.InSprintContext(ctx)
+}
+
+// InSprintContext is InSprintContext for c.
+func (c *Client) InSprintContext(ctx context.Context) bool {
+	if c.GetBead() == "" {
 		return false
 	}
-	if !HasIC() {
+	if !c.HasIC() {
 		return false
 	}
-	cmd := exec.Command("ic", "run", "current", "--project=.")
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Run() == nil
+	return c.icRunActive(ctx)
 }
 
 // --- Actions ---
 // Action functions return nothing — they are guaranteed no-ops when
 // dependencies are absent. Returning error would create dead code at
-// every call site (the spec says errors are never propagated).
+// every call site (the spec says errors are never propagated). Callers that
+// do need the outcome use the ...E variants (PhaseSetE, EmitEventE, ...),
+// which the plain functions wrap.
 
 // PhaseSet sets the phase on a bead. Silent no-op without bd.
+// A reason is recorded on the bead via `bd set-state --reason`. When ic is
+// available and a run is active, a phase.changed event carrying the old
+// phase, the new phase and the reason is emitted after a successful set.
+// See PhaseSetE for a variant that returns the error.
 func PhaseSet(bead, phase string, reason ...string) {
-	if !HasBD() {
-		return
+	defaultClient.PhaseSet(bead, phase, reason...)
+}
+
+// PhaseSet is PhaseSet for c.
+func (c *Client) PhaseSet(bead, phase string, reason ...string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.PhaseSetContext(ctx, bead, phase, reason...)
+}
+
+// PhaseSetContext is PhaseSet bounded by ctx. A timeout is logged, not returned.
+func PhaseSetContext(ctx context.Context, bead, phase string, reason ...string) {
+	defaultClient.PhaseSetContext(ctx, bead, phase, reason...)
+}
+
+// PhaseSetContext is PhaseSetContext for c.
+func (c *Client) PhaseSetContext(ctx context.Context, bead, phase string, reason ...string) {
+	c.logFailure(c.PhaseSetContextE(ctx, bead, phase, reason...), "bead", bead, "phase", phase)
+}
+
+// PhaseSetE is PhaseSet for callers that must know whether the phase was
+// recorded. It returns ErrNotAvailable without bd, or a *CommandError when
+// `bd set-state` fails (matching ErrTimeout if it timed out). The
+// phase.changed event stays best-effort and never affects the result.
+func PhaseSetE(bead, phase string, reason ...string) error {
+	return defaultClient.PhaseSetE(bead, phase, reason...)
+}
+
+// PhaseSetE is PhaseSetE for c.
+func (c *Client) PhaseSetE(bead, phase string, reason ...string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.PhaseSetContextE(ctx, bead, phase, reason...)
+}
+
+// PhaseSetContextE is PhaseSetE bounded by ctx.
+func PhaseSetContextE(ctx context.Context, bead, phase string, reason ...string) error {
+	return defaultClient.PhaseSetContextE(ctx, bead, phase, reason...)
+}
+
+// PhaseSetContextE is PhaseSetContextE for c.
+func (c *Client) PhaseSetContextE(ctx context.Context, bead, phase string, reason ...string) error {
+	if !c.HasBD() {
+		return notAvailable("bd")
 	}
-	cmd := exec.Command("bd", "set-state", bead, fmt.Sprintf("phase=%s", phase))
-	cmd.Stdout = nil
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "[interbase] bd set-state failed: %v\n", err)
+	why := ""
+	if len(reason) > 0 {
+		why = reason[0]
 	}
+	// The audit event needs an active run; without one, skip reading "from".
+	runID := ""
+	if c.HasIC() {
+		runID = c.defaultRunID(ctx)
+	}
+	from := ""
+	if runID != "" {
+		from = string(c.PhaseGetContext(ctx, bead))
+	}
+
+	if err := c.stateSet(ctx, bead, "phase", phase, why); err != nil {
+		return err
+	}
+	if runID != "" {
+		c.emitPhaseChanged(ctx, runID, phaseChanged{Bead: bead, From: from, To: phase, Reason: why})
+	}
+	return nil
 }
 
-// EmitEvent emits an event via ic. Silent no-op without ic.
+// EmitEvent emits an event via ic. Silent no-op without ic (unless the outbox
+// is enabled, see EnableOutbox). An empty runID means the active run from
+// `ic run current`; with no active run the call is a silent no-op. A payload
+// that is not valid JSON, or that does not match the schema registered for
+// eventType in package events, is logged and dropped before ic is
+// started. See EnableEnvelope for adding correlation fields to object
+// payloads, and EmitEventE for a variant that returns the error.
 func EmitEvent(runID, eventType string, payload ...string) {
-	if !HasIC() {
-		return
-	}
-	p := "{}"
-	if len(payload) > 0 && payload[0] != "" {
+	defaultClient.EmitEvent(runID, eventType, payload...)
+}
+
+// EmitEvent is EmitEvent for c.
+func (c *Client) EmitEvent(runID, eventType string, payload ...string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.EmitEventContext(ctx, runID, eventType, payload...)
+}
+
+// EmitEventContext is EmitEvent bounded by ctx. A timeout is logged, not returned.
+func EmitEventContext(ctx context.Context, runID, eventType string, payload ...string) {
+	defaultClient.EmitEventContext(ctx, runID, eventType, payload...)
+}
+
+// EmitEventContext is EmitEventContext for c.
+func (c *Client) EmitEventContext(ctx context.Context, runID, eventType string, payload ...string) {
+	c.logFailure(c.EmitEventContextE(ctx, runID, eventType, payload...), "event_type", eventType, "run_id", runID)
+}
+
+// EmitEventE is EmitEvent for callers that must know whether ic accepted the
+// event. It returns ErrNotAvailable without ic or without an active run for
+// an empty runID, a *CommandError when `ic events emit` fails (matching
+// ErrTimeout if it timed out), or an error describing an invalid payload
+// (wrapping *events.ValidationError for schema mismatches). An event kept
+// in the outbox still reports why it was not delivered.
+func EmitEventE(runID, eventType string, payload ...string) error {
+	return defaultClient.EmitEventE(runID, eventType, payload...)
+}
+
+// EmitEventE is EmitEventE for c.
+func (c *Client) EmitEventE(runID, eventType string, payload ...string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.EmitEventContextE(ctx, runID, eventType, payload...)
+}
+
+// EmitEventContextE is EmitEventE bounded by ctx.
+func EmitEventContextE(ctx context.Context, runID, eventType string, payload ...string) error {
+	return defaultClient.EmitEventContextE(ctx, runID, eventType, payload...)
+}
+
+// EmitEventContextE is EmitEventContextE for c.
+func (c *Client) EmitEventContextE(ctx context.Context, runID, eventType string, payload ...string) error {
+	p := ""
+	if len(payload) > 0 {
 		p = payload[0]
 	}
-	cmd := exec.Command("ic", "events", "emit", runID, eventType, fmt.Sprintf("--payload=%s", p))
-	cmd.Stdout = nil
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "[interbase] ic events emit failed: %v\n", err)
+	return c.emitEvent(ctx, runID, eventType, p, nil)
+}
+
+// emitEvent is EmitEventContextE with the envelope given, as captured when
+// an Emitter queued the event; nil builds it now.
+func (c *Client) emitEvent(ctx context.Context, runID, eventType, p string, env *Envelope) error {
+	hasIC := c.HasIC()
+	if !hasIC && c.outbox.Load() == nil {
+		return notAvailable("ic")
+	}
+	if p == "" {
+		p = "{}"
+	}
+	if !json.Valid([]byte(p)) {
+		return fmt.Errorf("ic events emit skipped: %s payload is not valid JSON", eventType)
+	}
+	if err := c.events.Validate(eventType, []byte(p)); err != nil {
+		return fmt.Errorf("ic events emit skipped: %w", err)
+	}
+	if hasIC && runID == "" {
+		if runID = c.defaultRunID(ctx); runID == "" {
+			return fmt.Errorf("%w: no active ic run", ErrNotAvailable)
+		}
+	}
+	p = c.wrapEnvelope(p, env)
+	if c.outbox.Load() != nil {
+		p = withEventID(p)
+	}
+	if !hasIC {
+		c.enqueue(runID, eventType, p)
+		return notAvailable("ic")
+	}
+	_, err := c.mutate(ctx, "ic events emit", "ic", "events", "emit", runID, eventType, fmt.Sprintf("--payload=%s", p))
+	if errors.Is(err, ErrNotAvailable) || errors.Is(err, ErrTransient) {
+		c.enqueue(runID, eventType, p) // ic rejected anything else; a replay would fail the same way
+	}
+	return err
+}
+
+// EmitEventJSON is EmitEvent with payload marshaled to JSON; a nil payload
+// sends "{}". Payloads that cannot be marshaled are logged and
+// dropped. Silent no-op without ic unless the outbox is enabled.
+func EmitEventJSON(runID, eventType string, payload any) {
+	defaultClient.EmitEventJSON(runID, eventType, payload)
+}
+
+// EmitEventJSON is EmitEventJSON for c.
+func (c *Client) EmitEventJSON(runID, eventType string, payload any) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.EmitEventJSONContext(ctx, runID, eventType, payload)
+}
+
+// EmitEventJSONContext is EmitEventJSON bounded by ctx.
+func EmitEventJSONContext(ctx context.Context, runID, eventType string, payload any) {
+	defaultClient.EmitEventJSONContext(ctx, runID, eventType, payload)
+}
+
+// EmitEventJSONContext is EmitEventJSONContext for c.
+func (c *Client) EmitEventJSONContext(ctx context.Context, runID, eventType string, payload any) {
+	c.logFailure(c.EmitEventJSONContextE(ctx, runID, eventType, payload), "event_type", eventType, "run_id", runID)
+}
+
+// EmitEventJSONE is EmitEventJSON returning the error, as EmitEventE does.
+func EmitEventJSONE(runID, eventType string, payload any) error {
+	return defaultClient.EmitEventJSONE(runID, eventType, payload)
+}
+
+// EmitEventJSONE is EmitEventJSONE for c.
+func (c *Client) EmitEventJSONE(runID, eventType string, payload any) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.EmitEventJSONContextE(ctx, runID, eventType, payload)
+}
+
+// EmitEventJSONContextE is EmitEventJSONE bounded by ctx.
+func EmitEventJSONContextE(ctx context.Context, runID, eventType string, payload any) error {
+	return defaultClient.EmitEventJSONContextE(ctx, runID, eventType, payload)
+}
+
+// EmitEventJSONContextE is EmitEventJSONContextE for c.
+func (c *Client) EmitEventJSONContextE(ctx context.Context, runID, eventType string, payload any) error {
+	if !c.HasIC() && c.outbox.Load() == nil {
+		return notAvailable("ic")
+	}
+	p := "{}"
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("ic events emit skipped: cannot marshal %s payload: %w", eventType, err)
+		}
+		p = string(b)
 	}
+	return c.EmitEventContextE(ctx, runID, eventType, p)
 }
 
 // SessionStatus returns the ecosystem status string.
 func SessionStatus() string {
+	return defaultClient.SessionStatus()
+}
+
+// SessionStatus is SessionStatus for c.
+func (c *Client) SessionStatus() string {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.SessionStatusContext(ctx)
+}
+
+// SessionStatusContext is SessionStatus bounded by ctx. An ic probe that
+// times out reports ic=not-initialized.
+func SessionStatusContext(ctx context.Context) string {
+	return defaultClient.SessionStatusContext(ctx)
+}
+
+// SessionStatusContext is SessionStatusContext for c.
+func (c *Client) SessionStatusContext(ctx context.Context) string {
 	var parts []string
 
-	if HasBD() {
+	if c.HasBD() {
 		parts = append(parts, "beads=active")
 	} else {
 		parts = append(parts, "beads=not-detected")
 	}
 
-	if HasIC() {
-		cmd := exec.Command("ic", "run", "current", "--project=.")
-		cmd.Stdout = nil
-		cmd.Stderr = nil
-		if cmd.Run() == nil {
+	if c.HasIC() {
+		if c.icRunActive(ctx) {
 			parts = append(parts, "ic=active")
 		} else {
 			parts = append(parts, "ic=not-initialized")
@@ -142,26 +399,28 @@ func SessionStatus() string {
 // PluginCachePath returns the cache path for a named plugin.
 // Returns empty string if not found.
 func PluginCachePath(plugin string) string {
-	if plugin == "" {
-		return ""
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	pattern := filepath.Join(home, ".claude", "plugins", "cache", "*", plugin, "*")
-	matches, err := filepath.Glob(pattern)
-	if err != nil || len(matches) == 0 {
+	return defaultClient.PluginCachePath(plugin)
+}
+
+// PluginCachePath is PluginCachePath for c.
+func (c *Client) PluginCachePath(plugin string) string {
+	// Highest semver across all marketplaces — see PluginVersions for ordering.
+	versions := c.PluginVersions(plugin)
+	if len(versions) == 0 {
 		return ""
 	}
-	// Return highest-versioned match (last after sort — Glob returns sorted)
-	return matches[len(matches)-1]
+	return versions[0].Path
 }
 
 // EcosystemRoot returns the Demarch monorepo root directory.
 // Checks $DEMARCH_ROOT first, then walks up from CWD.
 func EcosystemRoot() string {
-	if root := os.Getenv("DEMARCH_ROOT"); root != "" {
+	return defaultClient.EcosystemRoot()
+}
+
+// EcosystemRoot is EcosystemRoot for c.
+func (c *Client) EcosystemRoot() string {
+	if root := c.getenv("DEMARCH_ROOT"); root != "" {
 		return root
 	}
 	dir, err := os.Getwd()
@@ -169,7 +428,7 @@ func EcosystemRoot() string {
 		return ""
 	}
 	for {
-		if _, err := os.Stat(filepath.Join(dir, "sdk", "interbase")); err == nil {
+		if _, err := c.stat(filepath.Join(dir, "sdk", "interbase")); err == nil {
 			return dir
 		}
 		parent := filepath.Dir(dir)
@@ -184,10 +443,15 @@ func EcosystemRoot() string {
 // NudgeCompanion suggests installing a missing companion. Silent no-op if rate-limited.
 // Rate-limited to 2 nudges per session with durable dismiss after 3 ignores.
 func NudgeCompanion(companion, benefit string, plugin ...string) {
+	defaultClient.NudgeCompanion(companion, benefit, plugin...)
+}
+
+// NudgeCompanion is NudgeCompanion for c.
+func (c *Client) NudgeCompanion(companion, benefit string, plugin ...string) {
 	if companion == "" {
 		return
 	}
-	if HasCompanion(companion) {
+	if c.HasCompanion(companion) {
 		return
 	}
 
@@ -197,11 +461,11 @@ func NudgeCompanion(companion, benefit string, plugin ...string) {
 	}
 
 	// Session budget check — sanitize session ID for safe filename
-	sid := sanitizeID(os.Getenv("CLAUDE_SESSION_ID"))
+	sid := sanitizeID(c.getenv("CLAUDE_SESSION_ID"))
 	if sid == "" {
 		sid = "unknown"
 	}
-	stateDir := filepath.Join(userConfigDir(), "interverse")
+	stateDir := filepath.Join(c.userConfigDir(), "interverse")
 	sessionFile := filepath.Join(stateDir, fmt.Sprintf("nudge-session-%s.json", sid))
 
 	count := readNudgeCount(sessionFile)
@@ -216,18 +480,27 @@ func NudgeCompanion(companion, benefit string, plugin ...string) {
 	}
 
 	// Atomic dedup via mkdir — matches Bash/Python pattern. First caller wins.
-	os.MkdirAll(stateDir, 0755)
 	flag := filepath.Join(stateDir, fmt.Sprintf(".nudge-%s-%s-%s", sid, p, companion))
-	if err := os.Mkdir(flag, 0755); err != nil {
+	if err := c.mkdir(flag); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			c.log().Warn("nudge skipped: cannot create state dir", "companion", companion, "path", stateDir, "error", err)
+		}
 		return // another hook already emitted this nudge
 	}
 
 	// Emit nudge
-	fmt.Fprintf(os.Stderr, "[interverse] Tip: run /plugin install %s for %s.\n", companion, benefit)
+	tip := fmt.Sprintf("[interverse] Tip: run /plugin install %s for %s.\n", companion, benefit)
+	if !c.plan(PlannedAction{Kind: "print", Path: "stderr", Data: tip}) {
+		fmt.Fprint(os.Stderr, tip)
+	}
 
 	// Record
-	writeNudgeCount(sessionFile, count+1)
-	recordNudge(stateFile, p, companion)
+	if err := c.writeNudgeCount(sessionFile, count+1); err != nil {
+		c.log().Warn("nudge budget not recorded", "companion", companion, "path", sessionFile, "error", err)
+	}
+	if err := c.recordNudge(stateFile, p, companion); err != nil {
+		c.log().Warn("nudge state not recorded", "companion", companion, "path", stateFile, "error", err)
+	}
 }
 
 // --- Internal helpers ---
@@ -239,11 +512,11 @@ func sanitizeID(id string) string {
 	return safeIDRe.ReplaceAllString(id, "")
 }
 
-func userConfigDir() string {
-	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+func (c *Client) userConfigDir() string {
+	if d := c.getenv("XDG_CONFIG_HOME"); d != "" {
 		return d
 	}
-	home, _ := os.UserHomeDir()
+	home, _ := c.homeDir()
 	return filepath.Join(home, ".config")
 }
 
@@ -270,10 +543,9 @@ func readNudgeCount(path string) int {
 	return s.Count
 }
 
-func writeNudgeCount(path string, count int) {
-	os.MkdirAll(filepath.Dir(path), 0755)
+func (c *Client) writeNudgeCount(path string, count int) error {
 	data, _ := json.Marshal(nudgeSession{Count: count})
-	os.WriteFile(path, data, 0644)
+	return c.writeFile(path, data)
 }
 
 func isNudgeDismissed(stateFile, plugin, companion string) bool {
@@ -290,8 +562,7 @@ func isNudgeDismissed(stateFile, plugin, companion string) bool {
 	return ok && entry.Dismissed
 }
 
-func recordNudge(stateFile, plugin, companion string) {
-	os.MkdirAll(filepath.Dir(stateFile), 0755)
+func (c *Client) recordNudge(stateFile, plugin, companion string) error {
 	key := plugin + ":" + companion
 
 	var state map[string]nudgeEntry
@@ -312,5 +583,5 @@ func recordNudge(stateFile, plugin, companion string) {
 	state[key] = entry
 
 	out, _ := json.Marshal(state)
-	os.WriteFile(stateFile, out, 0644)
+	return c.writeFile(stateFile, out)
 }
@@ -1,11 +1,15 @@
 package interbase
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/mistakeknot/interbase/go/events"
 )
 
 func TestHasIC_WhenPresent(t *testing.T) {
@@ -157,3 +161,76 @@ func TestEcosystemRoot_Unset(t *testing.T) {
 	// Should return something or empty — just shouldn't panic
 	_ = EcosystemRoot()
 }
+
+func TestEmitEvent_InvalidJSONNotSent(t *testing.T) {
+	r := &fakeRunner{}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+
+	c.EmitEvent("run-1", "test-event", `{"broken":`)
+	if len(r.calls) != 0 {
+		t.Errorf("runner calls = %q, want none for invalid payload", r.calls)
+	}
+}
+
+func TestEmitEvent_SchemaMismatchNotSent(t *testing.T) {
+	reg := events.NewRegistry()
+	reg.MustRegister("review.completed", `{"type":"object","required":["bead"]}`)
+	r := &fakeRunner{results: map[string]fakeResult{
+		`ic events emit run-1 review.completed --payload={"bead":"iv-1"}`: {},
+		`ic events emit run-1 other.event --payload={}`:                   {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r), WithEventRegistry(reg))
+
+	c.EmitEvent("run-1", "review.completed", `{"score":4}`)
+	c.EmitEvent("run-1", "review.completed", `{"bead":"iv-1"}`)
+	c.EmitEvent("run-1", "other.event")
+
+	want := []string{
+		`ic events emit run-1 review.completed --payload={"bead":"iv-1"}`,
+		`ic events emit run-1 other.event --payload={}`,
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestEmitEvent_InvalidPayloadBeforeRunLookup(t *testing.T) {
+	reg := events.NewRegistry()
+	reg.MustRegister("x.strict", `{"required":["bead"]}`)
+	r := &fakeRunner{results: map[string]fakeResult{}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r), WithEventRegistry(reg))
+
+	if err := c.EmitEventE("", "x.y", "{bad"); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("EmitEventE(invalid JSON) = %v, want an invalid JSON error", err)
+	}
+	var ve *events.ValidationError
+	if err := c.EmitEventE("", "x.strict", "{}"); !errors.As(err, &ve) {
+		t.Errorf("EmitEventE(schema mismatch) = %v, want *events.ValidationError", err)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("runner calls = %q, want none for rejected payloads", r.calls)
+	}
+}
+
+func TestEmitEventJSON(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		`ic events emit run-1 review.completed --payload={"bead":"iv-1","score":4}`: {},
+		`ic events emit run-1 hook.fired --payload={}`:                              {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+
+	c.EmitEventJSON("run-1", "review.completed", struct {
+		Bead  string `json:"bead"`
+		Score int    `json:"score"`
+	}{"iv-1", 4})
+	c.EmitEventJSON("run-1", "hook.fired", nil)
+	c.EmitEventJSON("run-1", "bad.payload", map[string]any{"ch": make(chan int)})
+
+	want := []string{
+		`ic events emit run-1 review.completed --payload={"bead":"iv-1","score":4}`,
+		`ic events emit run-1 hook.fired --payload={}`,
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
@@ -0,0 +1,309 @@
+// Package interbasetest installs scriptable fake ic and bd executables for
+// testing code built on interbase.
+//
+// New writes shell-script fakes into a temp dir and prepends it to PATH for
+// the rest of the test, so the package-level interbase functions (and any
+// Client reading the process environment) find them instead of the real
+// tools. Every invocation is recorded; by default each one exits 0 with no
+// output, except `ic run current`, which fails until ActiveRun is called.
+// On scripts other answers:
+//
+//	env := interbasetest.New(t)
+//	env.ActiveRun("run-1")
+//	env.On("bd", "state", "iv-1", "phase").Stdout("planned")
+//
+//	interbase.PhaseSet("iv-1", "executing")
+//
+//	env.AssertPhaseSet(t, "iv-1", "executing")
+//	env.AssertEventEmitted(t, "phase.changed")
+//
+// New uses t.Setenv, so tests using it cannot run in parallel. The fakes are
+// /bin/sh scripts and are not available on Windows.
+package interbasetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	interbase "github.com/mistakeknot/interbase/go"
+)
+
+// Tools are the fakes New installs when none are named.
+var Tools = []string{"ic", "bd"}
+
+// Field and record separators in the invocation log (ASCII US and RS), so
+// arguments may hold spaces and newlines.
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+// fakeScript records the invocation, then answers from the newest matching
+// rule: a rule matches when its args equal the call's args or prefix them on
+// an argument boundary. It is a format string: cat and ls are filled in as
+// absolute paths so the fakes work under any PATH.
+const fakeScript = `#!/bin/sh
+tool=${0##*/}
+rec="$tool"
+for a in "$@"; do rec="$rec$(printf '\037')$a"; done
+printf '%%s\036' "$rec" >> "$INTERBASETEST_DIR/log"
+args="$*"
+rules="$INTERBASETEST_DIR/rules/$tool"
+for r in $(%[2]s -r "$rules" 2>/dev/null); do
+	p=$(%[1]s "$rules/$r/args")
+	match=
+	if [ -z "$p" ]; then match=1; fi
+	case "$args" in "$p"|"$p "*) match=1 ;; esac
+	if [ -n "$match" ]; then
+		%[1]s "$rules/$r/stdout"
+		%[1]s "$rules/$r/stderr" >&2
+		exit "$(%[1]s "$rules/$r/exit")"
+	fi
+done
+exit 0
+`
+
+// Env is a set of installed fakes.
+type Env struct {
+	t    testing.TB
+	dir  string
+	path string
+
+	mu    sync.Mutex
+	rules int
+}
+
+// New installs fakes for tools (default Tools) and prepends them to PATH
+// until the test ends. Interbase probe caches are refreshed on both ends so
+// results from the real tools do not leak in or out.
+func New(t testing.TB, tools ...string) *Env {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("interbasetest fakes need /bin/sh")
+	}
+	if len(tools) == 0 {
+		tools = Tools
+	}
+	cat, err1 := exec.LookPath("cat")
+	ls, err2 := exec.LookPath("ls")
+	if err1 != nil || err2 != nil {
+		t.Skip("interbasetest fakes need cat and ls")
+	}
+	script := fmt.Sprintf(fakeScript, cat, ls)
+
+	dir := t.TempDir()
+	e := &Env{t: t, dir: dir, path: dir}
+	if old := os.Getenv("PATH"); old != "" {
+		e.path += string(os.PathListSeparator) + old
+	}
+	for _, tool := range tools {
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte(script), 0755); err != nil {
+			t.Fatalf("interbasetest: write fake %s: %v", tool, err)
+		}
+	}
+	t.Setenv("INTERBASETEST_DIR", dir)
+	t.Setenv("PATH", e.path)
+	for _, tool := range tools {
+		if tool == "ic" {
+			e.On("ic", "run", "current").Exit(1) // no active run until ActiveRun
+		}
+	}
+	interbase.Refresh()
+	t.Cleanup(interbase.Refresh)
+	return e
+}
+
+// Dir is the directory holding the fakes.
+func (e *Env) Dir() string { return e.dir }
+
+// Path is the PATH the fakes were installed with: Dir, then the PATH that was
+// in effect when New ran. Pass it, with INTERBASETEST_DIR=Dir, to Clients
+// built with interbase.WithEnv.
+func (e *Env) Path() string { return e.path }
+
+// Stub is one scripted answer. Its setters return the Stub for chaining.
+type Stub struct {
+	e   *Env
+	dir string
+}
+
+// On scripts tool's answer to calls whose arguments start with args (all
+// calls if args is empty). The newest matching stub wins, so a test can
+// override an earlier, broader one. A new stub exits 0 with no output.
+func (e *Env) On(tool string, args ...string) *Stub {
+	e.t.Helper()
+	e.mu.Lock()
+	e.rules++
+	n := e.rules
+	e.mu.Unlock()
+	s := &Stub{e: e, dir: filepath.Join(e.dir, "rules", tool, fmt.Sprintf("%06d", n))}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		e.t.Fatalf("interbasetest: %v", err)
+	}
+	s.write("args", strings.Join(args, " "))
+	s.write("stdout", "")
+	s.write("stderr", "")
+	s.write("exit", "0")
+	return s
+}
+
+// Stdout sets what the call prints on stdout.
+func (s *Stub) Stdout(out string) *Stub { s.write("stdout", out); return s }
+
+// Stderr sets what the call prints on stderr.
+func (s *Stub) Stderr(out string) *Stub { s.write("stderr", out); return s }
+
+// Exit sets the call's exit code.
+func (s *Stub) Exit(code int) *Stub { s.write("exit", strconv.Itoa(code)); return s }
+
+func (s *Stub) write(name, content string) {
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644); err != nil {
+		s.e.t.Fatalf("interbasetest: %v", err)
+	}
+}
+
+// ActiveRun makes `ic run current` report runID as the active run.
+func (e *Env) ActiveRun(runID string) *Stub {
+	return e.On("ic", "run", "current").Stdout(runID)
+}
+
+// Version makes `tool --version` report version.
+func (e *Env) Version(tool, version string) *Stub {
+	return e.On(tool, "--version").Stdout(tool + " version " + version)
+}
+
+// Invocation is one recorded call of a fake.
+type Invocation struct {
+	Tool string
+	Args []string
+}
+
+// String renders the command line.
+func (i Invocation) String() string {
+	return strings.Join(append([]string{i.Tool}, i.Args...), " ")
+}
+
+// Invocations returns every recorded call, oldest first.
+func (e *Env) Invocations() []Invocation {
+	e.t.Helper()
+	data, err := os.ReadFile(filepath.Join(e.dir, "log"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		e.t.Fatalf("interbasetest: %v", err)
+	}
+	var calls []Invocation
+	for _, rec := range strings.Split(strings.TrimSuffix(string(data), recordSep), recordSep) {
+		if rec == "" {
+			continue
+		}
+		fields := strings.Split(rec, fieldSep)
+		calls = append(calls, Invocation{Tool: fields[0], Args: fields[1:]})
+	}
+	return calls
+}
+
+// Commands returns every recorded call as a command line, oldest first.
+func (e *Env) Commands() []string {
+	e.t.Helper()
+	calls := e.Invocations()
+	lines := make([]string, len(calls))
+	for i, c := range calls {
+		lines[i] = c.String()
+	}
+	return lines
+}
+
+// Event is an `ic events emit` call.
+type Event struct {
+	RunID   string
+	Type    string
+	Payload json.RawMessage
+}
+
+// Events returns the events emitted through the ic fake, oldest first.
+func (e *Env) Events() []Event {
+	e.t.Helper()
+	var evs []Event
+	for _, c := range e.Invocations() {
+		if c.Tool != "ic" || len(c.Args) < 4 || c.Args[0] != "events" || c.Args[1] != "emit" {
+			continue
+		}
+		ev := Event{RunID: c.Args[2], Type: c.Args[3], Payload: json.RawMessage("{}")}
+		for _, a := range c.Args[4:] {
+			if p, ok := strings.CutPrefix(a, "--payload="); ok {
+				ev.Payload = json.RawMessage(p)
+			}
+		}
+		evs = append(evs, ev)
+	}
+	return evs
+}
+
+// AssertCalled fails t unless tool was called with arguments starting with
+// args.
+func (e *Env) AssertCalled(t testing.TB, tool string, args ...string) {
+	t.Helper()
+	want := Invocation{Tool: tool, Args: args}.String()
+	for _, line := range e.Commands() {
+		if line == want || strings.HasPrefix(line, want+" ") {
+			return
+		}
+	}
+	t.Errorf("interbasetest: %q was not called; calls: %q", want, e.Commands())
+}
+
+// AssertNotCalled fails t if tool was called at all.
+func (e *Env) AssertNotCalled(t testing.TB, tool string) {
+	t.Helper()
+	for _, c := range e.Invocations() {
+		if c.Tool == tool {
+			t.Errorf("interbasetest: %s was called: %q", tool, c.String())
+			return
+		}
+	}
+}
+
+// AssertEventEmitted fails t unless an event of eventType was emitted, and
+// returns the most recent one.
+func (e *Env) AssertEventEmitted(t testing.TB, eventType string) Event {
+	t.Helper()
+	evs := e.Events()
+	for i := len(evs) - 1; i >= 0; i-- {
+		if evs[i].Type == eventType {
+			return evs[i]
+		}
+	}
+	types := make([]string, len(evs))
+	for i, ev := range evs {
+		types[i] = ev.Type
+	}
+	t.Errorf("interbasetest: no %s event emitted; emitted: %q", eventType, types)
+	return Event{}
+}
+
+// AssertNoEvent fails t if an event of eventType was emitted.
+func (e *Env) AssertNoEvent(t testing.TB, eventType string) {
+	t.Helper()
+	for _, ev := range e.Events() {
+		if ev.Type == eventType {
+			t.Errorf("interbasetest: unexpected %s event: %s", eventType, ev.Payload)
+			return
+		}
+	}
+}
+
+// AssertPhaseSet fails t unless bd recorded phase on bead.
+func (e *Env) AssertPhaseSet(t testing.TB, bead, phase string) {
+	t.Helper()
+	e.AssertCalled(t, "bd", "set-state", bead, "phase="+phase)
+}
@@ -0,0 +1,78 @@
+package interbasetest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	interbase "github.com/mistakeknot/interbase/go"
+	"github.com/mistakeknot/interbase/go/interbasetest"
+)
+
+func TestPhaseSetEmitsPhaseChanged(t *testing.T) {
+	env := interbasetest.New(t)
+	env.ActiveRun("run-1")
+	env.On("bd", "state", "iv-1", "phase").Stdout("planned")
+
+	interbase.PhaseSet("iv-1", "executing", "plan approved")
+
+	env.AssertPhaseSet(t, "iv-1", "executing")
+	ev := env.AssertEventEmitted(t, "phase.changed")
+	if ev.RunID != "run-1" {
+		t.Errorf("RunID = %q, want run-1", ev.RunID)
+	}
+	var p map[string]string
+	if err := json.Unmarshal(ev.Payload, &p); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	want := map[string]string{"bead": "iv-1", "from": "planned", "to": "executing", "reason": "plan approved"}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("payload = %v, want %v", p, want)
+	}
+}
+
+func TestInSprint(t *testing.T) {
+	env := interbasetest.New(t)
+	t.Setenv("CLAVAIN_BEAD_ID", "iv-1")
+
+	if interbase.InSprint() {
+		t.Error("InSprint() = true with no active run scripted")
+	}
+	interbase.Refresh()
+	env.ActiveRun("run-1")
+	if !interbase.InSprint() {
+		t.Error("InSprint() = false with an active run")
+	}
+}
+
+func TestScriptedFailure(t *testing.T) {
+	env := interbasetest.New(t)
+	env.On("bd", "set-state").Stderr("database is locked").Exit(5)
+	env.On("bd", "set-state", "iv-2").Exit(0) // newer, narrower stub wins
+
+	err := interbase.PhaseSetE("iv-1", "done")
+	var ce *interbase.CommandError
+	if !errors.As(err, &ce) || ce.ExitCode != 5 || string(ce.Stderr) != "database is locked" {
+		t.Errorf("PhaseSetE(iv-1) = %v, want exit 5 with stderr", err)
+	}
+	if err := interbase.PhaseSetE("iv-2", "done"); err != nil {
+		t.Errorf("PhaseSetE(iv-2) = %v, want nil", err)
+	}
+}
+
+func TestInvocationsKeepArguments(t *testing.T) {
+	env := interbasetest.New(t, "ic")
+
+	interbase.EmitEvent("run-1", "note.added", `{"text": "two words\nand a line"}`)
+
+	want := []interbasetest.Invocation{{
+		Tool: "ic",
+		Args: []string{"events", "emit", "run-1", "note.added", "--payload={\"text\": \"two words\\nand a line\"}"},
+	}}
+	if got := env.Invocations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Invocations() = %q, want %q", got, want)
+	}
+	env.AssertNotCalled(t, "bd")
+	env.AssertNoEvent(t, "phase.changed")
+}
@@ -0,0 +1,204 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelQuiet is above every level interbase logs at; a logger at LevelQuiet
+// prints nothing.
+const LevelQuiet = slog.LevelError + 4
+
+// noLevel marks a Client whose log level was never set.
+const noLevel = int64(-1 << 62)
+
+// SetLogger routes interbase's diagnostics to l, which then decides what to
+// keep. nil restores the built-in stderr logger.
+//
+// The built-in logger writes "[interbase] message key=value ..." lines to
+// stderr. Its level comes from SetLogLevel, else $INTERBASE_LOG_LEVEL
+// (debug, info, warn, error or quiet), else warn inside the ecosystem and
+// LevelQuiet in standalone mode, so a standalone plugin never prints errors.
+func SetLogger(l *slog.Logger) {
+	defaultClient.SetLogger(l)
+}
+
+// SetLogger is SetLogger for c.
+func (c *Client) SetLogger(l *slog.Logger) {
+	c.logger.Store(l)
+}
+
+// SetLogLevel sets the level of the built-in stderr logger, overriding
+// $INTERBASE_LOG_LEVEL and the standalone default. It has no effect on a
+// logger passed to SetLogger.
+func SetLogLevel(level slog.Level) {
+	defaultClient.SetLogLevel(level)
+}
+
+// SetLogLevel is SetLogLevel for c.
+func (c *Client) SetLogLevel(level slog.Level) {
+	c.logLevel.Store(int64(level))
+}
+
+// log returns the logger for c's diagnostics.
+func (c *Client) log() *slog.Logger {
+	if l := c.logger.Load(); l != nil {
+		return l
+	}
+	return c.stderrLog
+}
+
+// level is the built-in logger's current level.
+func (c *Client) level() slog.Level {
+	if l := c.logLevel.Load(); l != noLevel {
+		return slog.Level(l)
+	}
+	if l, ok := parseLogLevel(c.getenv("INTERBASE_LOG_LEVEL")); ok {
+		return l
+	}
+	return c.defaultLevel()
+}
+
+// defaultLevel is warn inside the ecosystem and LevelQuiet outside it. level
+// runs on every log call, so the ecosystem stat goes through the probe cache.
+func (c *Client) defaultLevel() slog.Level {
+	key := "in-ecosystem\x00" + c.getenv("INTERMOD_LIB") + "\x00" + c.getenv("HOME")
+	if _, in := c.probes.do(context.Background(), key, func() (string, bool) {
+		return "", c.InEcosystem()
+	}); in {
+		return slog.LevelWarn
+	}
+	return LevelQuiet
+}
+
+func parseLogLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "quiet", "off", "none":
+		return LevelQuiet, true
+	case "":
+		return 0, false
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, false
+	}
+	return l, true
+}
+
+// logFailure is the fail-open half of every action: errors are logged, and a
+// missing tool is only worth a debug line. attrs describe the action (bead,
+// event type, ...); a *CommandError adds the command, exit code and stderr.
+func (c *Client) logFailure(err error, attrs ...any) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrNotAvailable) {
+		c.log().Debug("skipped: "+err.Error(), attrs...)
+		return
+	}
+	var ce *CommandError
+	if errors.As(err, &ce) {
+		attrs = append(attrs,
+			slog.String("command", strings.Join(ce.Args, " ")),
+			slog.Int("exit_code", ce.ExitCode),
+			slog.String("error", ce.Err.Error()),
+		)
+		if s := strings.TrimSpace(string(ce.Stderr)); s != "" {
+			attrs = append(attrs, slog.String("stderr", s))
+		}
+		c.log().Warn(ce.Op+" failed", attrs...)
+		return
+	}
+	c.log().Warn(err.Error(), attrs...)
+}
+
+// stderrHandler is the built-in slog.Handler: one "[interbase] msg k=v"
+// line per record, filtered by the owning Client's level.
+type stderrHandler struct {
+	level  func() slog.Level
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string // pre-rendered WithAttrs attributes
+	group  string // WithGroup key prefix, with trailing "."
+}
+
+func newStderrHandler(level func() slog.Level) *stderrHandler {
+	return &stderrHandler{level: level, mu: new(sync.Mutex), w: os.Stderr}
+}
+
+func (h *stderrHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level()
+}
+
+func (h *stderrHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString("[interbase] ")
+	b.WriteString(r.Message)
+	b.WriteString(h.prefix)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.group, a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *stderrHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		writeAttr(&b, h.group, a)
+	}
+	h2 := *h
+	h2.prefix += b.String()
+	return &h2
+}
+
+func (h *stderrHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+func writeAttr(b *strings.Builder, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		g := group
+		if a.Key != "" {
+			g += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, g, ga)
+		}
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(group + a.Key)
+	b.WriteByte('=')
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		s = a.Value.Time().Format(time.RFC3339)
+	default:
+		s = a.Value.String()
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = strconv.Quote(s)
+	}
+	b.WriteString(s)
+}
@@ -0,0 +1,126 @@
+package interbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLogLevel_Defaults(t *testing.T) {
+	eco := fstest.MapFS{"home/a/.intermod/interbase/interbase.sh": {}}
+	tests := []struct {
+		name string
+		opts []Option
+		want slog.Level
+	}{
+		{"standalone", []Option{WithEnv(map[string]string{"HOME": "/home/a"}), WithFS(fstest.MapFS{})}, LevelQuiet},
+		{"ecosystem", []Option{WithEnv(map[string]string{"HOME": "/home/a"}), WithFS(eco)}, slog.LevelWarn},
+		{"env", []Option{WithEnv(map[string]string{"HOME": "/home/a", "INTERBASE_LOG_LEVEL": "debug"}), WithFS(fstest.MapFS{})}, slog.LevelDebug},
+		{"env quiet", []Option{WithEnv(map[string]string{"HOME": "/home/a", "INTERBASE_LOG_LEVEL": "quiet"}), WithFS(eco)}, LevelQuiet},
+		{"option", []Option{WithEnv(map[string]string{"INTERBASE_LOG_LEVEL": "debug"}), WithLogLevel(slog.LevelError)}, slog.LevelError},
+	}
+	for _, tt := range tests {
+		if got := New(tt.opts...).level(); got != tt.want {
+			t.Errorf("%s: level() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// statCounter counts the Stat calls made through it.
+type statCounter struct {
+	fstest.MapFS
+	n *int
+}
+
+func (f statCounter) Stat(name string) (fs.FileInfo, error) {
+	*f.n++
+	return f.MapFS.Stat(name)
+}
+
+func TestLogLevel_EcosystemCheckCached(t *testing.T) {
+	var stats int
+	c := New(
+		WithEnv(map[string]string{"HOME": "/home/a"}),
+		WithFS(statCounter{fstest.MapFS{}, &stats}),
+		WithLookPath(onPath()),
+	)
+
+	for range 5 {
+		c.EmitEvent("run-1", "a.one") // no ic: a debug line each time
+	}
+	if stats != 1 {
+		t.Errorf("ecosystem stats = %d, want 1 per probe TTL", stats)
+	}
+	c.Refresh()
+	c.log().Debug("again")
+	if stats != 2 {
+		t.Errorf("ecosystem stats after Refresh = %d, want 2", stats)
+	}
+}
+
+func TestLogFailure_Attributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := &fakeRunner{results: map[string]fakeResult{}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r), WithLogger(logger))
+
+	c.PhaseSet("iv-1", "executing")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("log output %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":     "WARN",
+		"msg":       "bd set-state failed",
+		"bead":      "iv-1",
+		"phase":     "executing",
+		"command":   "bd set-state iv-1 phase=executing",
+		"exit_code": float64(-1),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("log %s = %v, want %v", k, rec[k], v)
+		}
+	}
+
+	buf.Reset()
+	c.EmitEvent("run-1", "a.one") // no ic: debug only
+	if !strings.Contains(buf.String(), `"level":"DEBUG"`) {
+		t.Errorf("missing-tool log = %q, want a DEBUG record", buf.String())
+	}
+}
+
+func TestStderrHandler_Format(t *testing.T) {
+	var buf bytes.Buffer
+	h := newStderrHandler(func() slog.Level { return slog.LevelWarn })
+	h.w = &buf
+	l := slog.New(h).With("bead", "iv-1").WithGroup("cmd")
+
+	l.Info("hidden")
+	l.Warn("bd set-state failed", "exit_code", 3, "stderr", "database is locked")
+
+	want := `[interbase] bd set-state failed bead=iv-1 cmd.exit_code=3 cmd.stderr="database is locked"` + "\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestQuietLogger_Silent(t *testing.T) {
+	var buf bytes.Buffer
+	h := newStderrHandler(func() slog.Level { return LevelQuiet })
+	h.w = &buf
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath()))
+	c.stderrLog = slog.New(h)
+
+	c.logFailure(errors.New("anything"))
+	c.log().Error("even errors")
+	if buf.Len() != 0 {
+		t.Errorf("quiet logger wrote %q", buf.String())
+	}
+}
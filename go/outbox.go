@@ -0,0 +1,452 @@
+package interbase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Outbox size caps applied when OutboxConfig leaves them zero.
+const (
+	DefaultOutboxMaxEvents = 1000
+	DefaultOutboxMaxBytes  = 1 << 20
+)
+
+// Outbox file names under the interverse state dir: pending events, and
+// events ic rejected during a flush.
+const (
+	outboxFile     = "event-outbox.jsonl"
+	deadLetterFile = "event-outbox.dead.jsonl"
+)
+
+// Lock and in-flight flush timing. A lock older than outboxLockStale, or a
+// flush claim older than outboxFlushStale, was left by a process that died.
+const (
+	outboxLockWait   = time.Second
+	outboxLockStale  = 30 * time.Second
+	outboxFlushStale = 10 * time.Minute
+)
+
+// OutboxConfig caps the event outbox. Zero fields use the defaults.
+type OutboxConfig struct {
+	MaxEvents int   // events held at once (DefaultOutboxMaxEvents)
+	MaxBytes  int64 // size of the outbox file (DefaultOutboxMaxBytes)
+}
+
+// outboxEntry is one JSONL line of the outbox.
+type outboxEntry struct {
+	ID        string          `json:"id"`
+	RunID     string          `json:"run_id,omitempty"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	QueuedAt  time.Time       `json:"queued_at"`
+	Error     string          `json:"error,omitempty"` // dead letters: why ic rejected it
+}
+
+// EnableOutbox makes EmitEvent keep events it could not deliver — because ic
+// is missing, its circuit breaker is open, or `ic events emit` failed
+// transiently (see ErrTransient) — in a JSONL outbox under the interverse
+// state dir, for FlushOutbox to replay later. Events ic rejects outright are
+// not kept: a replay would fail the same way. Events without a run ID (an
+// empty runID and no ic to resolve it) are dropped, since the run active at
+// flush time need not be theirs. Off by default.
+//
+// While enabled, EmitEvent gives every object payload without a top-level
+// "event_id" string a random one before the first send, so a queued copy of
+// an event ic did commit (say, before a timeout) can be recognized
+// downstream. The outbox itself is deduplicated by that ID, so a retried
+// emit with the same event_id is queued once; non-object payloads get a
+// local ID only. When a cap is reached new events are logged and dropped;
+// queued events are never evicted.
+func EnableOutbox(cfg OutboxConfig) {
+	defaultClient.EnableOutbox(cfg)
+}
+
+// EnableOutbox is EnableOutbox for c.
+func (c *Client) EnableOutbox(cfg OutboxConfig) {
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultOutboxMaxEvents
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultOutboxMaxBytes
+	}
+	c.outbox.Store(&cfg)
+}
+
+// DisableOutbox stops queueing undelivered events. Events already queued
+// stay on disk for FlushOutbox.
+func DisableOutbox() {
+	defaultClient.DisableOutbox()
+}
+
+// DisableOutbox is DisableOutbox for c.
+func (c *Client) DisableOutbox() {
+	c.outbox.Store(nil)
+}
+
+// FlushOutbox replays queued events through `ic events emit`, oldest first,
+// and returns how many were delivered. It stops at the first event that
+// fails transiently or finds ic unavailable, keeping it and everything after
+// it for the next flush. An event ic rejects outright is moved to
+// event-outbox.dead.jsonl, with the error, and the flush goes on. Each event
+// leaves the flush's claim as soon as it is handled, so a flush that dies is
+// resumed from the first unhandled event, without resending. Returns 0
+// without ic or while another process is flushing. Works whether or not the
+// outbox is currently enabled.
+func FlushOutbox() int {
+	return defaultClient.FlushOutbox()
+}
+
+// FlushOutbox is FlushOutbox for c.
+func (c *Client) FlushOutbox() int {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.FlushOutboxContext(ctx)
+}
+
+// FlushOutboxContext is FlushOutbox bounded by ctx. Events not delivered
+// before ctx is done stay queued.
+func FlushOutboxContext(ctx context.Context) int {
+	return defaultClient.FlushOutboxContext(ctx)
+}
+
+// FlushOutboxContext is FlushOutboxContext for c.
+func (c *Client) FlushOutboxContext(ctx context.Context) int {
+	if !c.HasIC() {
+		return 0
+	}
+	path := c.outboxPath()
+	if c.dryRun.Load() != nil {
+		return c.planFlush(ctx, path)
+	}
+	pending, ok := c.claimOutbox(path)
+	if !ok || len(pending) == 0 {
+		return 0
+	}
+
+	sent, next := 0, 0
+	for ; next < len(pending); next++ {
+		e := pending[next]
+		if e.RunID == "" {
+			e.Error = "no run ID"
+			c.deadLetter(e)
+			c.trimClaim(path, pending[next+1:])
+			continue
+		}
+		_, err := c.runE(ctx, "ic events emit", "ic", "events", "emit", e.RunID, e.EventType, "--payload="+string(e.Payload))
+		if err == nil {
+			sent++
+			c.trimClaim(path, pending[next+1:])
+			continue
+		}
+		c.logFailure(err, "event_type", e.EventType, "run_id", e.RunID)
+		if errors.Is(err, ErrNotAvailable) || errors.Is(err, ErrTransient) {
+			c.log().Warn("event outbox flush stopped", "pending", len(pending)-next)
+			break
+		}
+		e.Error = err.Error()
+		c.deadLetter(e)
+		c.trimClaim(path, pending[next+1:])
+	}
+	c.releaseOutbox(path, pending[next:])
+	return sent
+}
+
+// trimClaim rewrites the flush claim with the events not yet handled, so a
+// flush that dies, or cannot release its claim, replays only those.
+func (c *Client) trimClaim(path string, rest []outboxEntry) {
+	if err := writeOutbox(path+".flushing", rest); err != nil {
+		c.log().Debug("event outbox claim not trimmed", "path", path+".flushing", "error", err)
+	}
+}
+
+// planFlush is FlushOutbox in dry-run mode: it plans the emits for what is
+// queued and leaves the outbox untouched.
+func (c *Client) planFlush(ctx context.Context, path string) int {
+	pending, _ := readOutbox(path)
+	sent := 0
+	for _, e := range pending {
+		if e.RunID != "" {
+			c.mutate(ctx, "ic events emit", "ic", "events", "emit", e.RunID, e.EventType, "--payload="+string(e.Payload))
+			sent++
+		}
+	}
+	return sent
+}
+
+// outboxPath is the outbox file for c's environment.
+func (c *Client) outboxPath() string {
+	return filepath.Join(c.userConfigDir(), "interverse", outboxFile)
+}
+
+// enqueue appends an undelivered event to the outbox, if enabled.
+func (c *Client) enqueue(runID, eventType, payload string) {
+	cfg := c.outbox.Load()
+	if cfg == nil {
+		return
+	}
+	if runID == "" {
+		c.log().Debug("event outbox: no run ID, dropping event", "event_type", eventType)
+		return
+	}
+	e := outboxEntry{
+		ID:        eventID(payload),
+		RunID:     runID,
+		EventType: eventType,
+		Payload:   json.RawMessage(payload),
+		QueuedAt:  c.now().UTC(),
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		c.log().Warn("event outbox: cannot encode event", "event_type", eventType, "error", err)
+		return
+	}
+	line = append(line, '\n')
+
+	path := c.outboxPath()
+	if c.plan(PlannedAction{Kind: "append", Path: path, Data: string(line)}) {
+		return
+	}
+	unlock, ok := lockOutbox(path)
+	if !ok {
+		c.log().Warn("event outbox locked, dropping event", "event_type", eventType, "path", path)
+		return
+	}
+	defer unlock()
+
+	queued, size := readOutbox(path)
+	inflight, _ := readOutbox(path + ".flushing")
+	for _, q := range append(queued, inflight...) {
+		if q.ID == e.ID {
+			return
+		}
+	}
+	if len(queued) >= cfg.MaxEvents || size+int64(len(line)) > cfg.MaxBytes {
+		c.log().Warn("event outbox full, dropping event", "event_type", eventType, "queued", len(queued), "bytes", size)
+		return
+	}
+	if err := appendFile(path, line); err != nil {
+		c.log().Warn("event outbox write failed", "path", path, "error", err)
+	}
+}
+
+// deadLetter appends an event ic rejected, or that has no run ID, to the
+// dead-letter file, up to DefaultOutboxMaxBytes; beyond that it is logged
+// and dropped.
+func (c *Client) deadLetter(e outboxEntry) {
+	path := filepath.Join(c.userConfigDir(), "interverse", deadLetterFile)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	if size+int64(len(line)) > DefaultOutboxMaxBytes {
+		c.log().Warn("event dead-letter file full, dropping rejected event", "path", path, "event_type", e.EventType)
+		return
+	}
+	if err := appendFile(path, line); err != nil {
+		c.log().Warn("event dead-letter write failed", "path", path, "error", err)
+		return
+	}
+	c.log().Warn("event rejected by ic moved to dead letters", "path", path, "event_type", e.EventType, "error", e.Error)
+}
+
+// claimOutbox moves the queued events into path.flushing so that new events
+// queue behind them while they are replayed. A claim left by a crashed flush
+// is adopted once stale; a live one means another process is flushing.
+func (c *Client) claimOutbox(path string) ([]outboxEntry, bool) {
+	unlock, ok := lockOutbox(path)
+	if !ok {
+		return nil, false
+	}
+	defer unlock()
+
+	flushing := path + ".flushing"
+	if info, err := os.Stat(flushing); err == nil && time.Since(info.ModTime()) < outboxFlushStale {
+		return nil, false
+	}
+	leftover, _ := readOutbox(flushing)
+	queued, _ := readOutbox(path)
+	pending := dedupeOutbox(append(leftover, queued...))
+	if len(pending) == 0 {
+		os.Remove(flushing)
+		os.Remove(path)
+		return nil, true
+	}
+	if err := writeOutbox(flushing, pending); err != nil {
+		c.log().Warn("event outbox flush failed", "path", flushing, "error", err)
+		return nil, false
+	}
+	os.Remove(path)
+	return pending, true
+}
+
+// releaseOutbox puts the undelivered events back in front of any queued
+// during the flush and drops the claim.
+func (c *Client) releaseOutbox(path string, rest []outboxEntry) {
+	unlock, ok := lockOutbox(path)
+	if !ok {
+		c.log().Warn("event outbox locked, events held until the next flush", "pending", len(rest))
+		return
+	}
+	defer unlock()
+
+	queued, _ := readOutbox(path)
+	remaining := dedupeOutbox(append(rest, queued...))
+	var err error
+	if len(remaining) == 0 {
+		err = os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	} else {
+		err = writeOutbox(path, remaining)
+	}
+	if err != nil {
+		c.log().Warn("event outbox write failed", "path", path, "error", err)
+		return
+	}
+	os.Remove(path + ".flushing")
+}
+
+// eventID is the outbox dedupe key: the payload's own event_id, if any.
+func eventID(payload string) string {
+	var p struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal([]byte(payload), &p); err == nil && p.EventID != "" {
+		return p.EventID
+	}
+	return randomID()
+}
+
+// withEventID adds a random top-level event_id to an object payload that
+// has none, so the ID the outbox dedupes by is also the one ic receives.
+func withEventID(payload string) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil || fields == nil {
+		return payload
+	}
+	if _, ok := fields["event_id"]; ok {
+		return payload
+	}
+	fields["event_id"], _ = json.Marshal(randomID())
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return string(out)
+}
+
+func randomID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// readOutbox returns the entries in an outbox file and its size. Lines that
+// do not decode are skipped.
+func readOutbox(path string) ([]outboxEntry, int64) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0
+	}
+	var entries []outboxEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		var e outboxEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.ID == "" || e.EventType == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, int64(len(data))
+}
+
+// writeOutbox replaces path with entries, atomically.
+func writeOutbox(path string, entries []outboxEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// dedupeOutbox keeps the first entry for each ID.
+func dedupeOutbox(entries []outboxEntry) []outboxEntry {
+	seen := make(map[string]bool, len(entries))
+	out := entries[:0:0]
+	for _, e := range entries {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// lockOutbox takes the cross-process outbox lock, a file created with
+// O_EXCL, waiting up to outboxLockWait. Stale locks are broken.
+func lockOutbox(path string) (unlock func(), ok bool) {
+	lock := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0755); err != nil {
+		return nil, false
+	}
+	deadline := time.Now().Add(outboxLockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, true
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, false
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > outboxLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,293 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func outboxClient(dir string, r Runner, tools ...string) *Client {
+	return New(
+		WithEnv(map[string]string{"XDG_CONFIG_HOME": dir}),
+		WithLookPath(onPath(tools...)),
+		WithRunner(r),
+		WithOutbox(OutboxConfig{}),
+	)
+}
+
+// withoutEventID drops the event_id EmitEvent stamps into object payloads
+// while the outbox is on, so expectations can be written without it.
+func withoutEventID(payload string) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil || fields == nil {
+		return payload
+	}
+	delete(fields, "event_id")
+	out, _ := json.Marshal(fields)
+	return string(out)
+}
+
+func outboxLines(t *testing.T, c *Client, file ...string) []string {
+	t.Helper()
+	path := c.outboxPath()
+	if len(file) > 0 {
+		path = filepath.Join(filepath.Dir(path), file[0])
+	}
+	entries, _ := readOutbox(path)
+	var lines []string
+	for _, e := range entries {
+		lines = append(lines, e.RunID+" "+e.EventType+" "+withoutEventID(string(e.Payload)))
+	}
+	return lines
+}
+
+// icResult is a scripted `ic events emit` outcome for icRunner.
+type icResult struct {
+	stderr string
+	code   int // 0 succeeds
+}
+
+var (
+	icLocked   = icResult{stderr: "database is locked", code: 1}
+	icRejected = icResult{stderr: "unknown event type", code: 2}
+)
+
+// icRunner answers ic calls from results, keyed by command line with the
+// payload's event_id dropped; anything else is rejected.
+func icRunner(results map[string]icResult) *RecordingRunner {
+	return &RecordingRunner{Next: RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		line := []string{name}
+		for _, a := range args {
+			if p, ok := strings.CutPrefix(a, "--payload="); ok {
+				a = "--payload=" + withoutEventID(p)
+			}
+			line = append(line, a)
+		}
+		res, ok := results[strings.Join(line, " ")]
+		if !ok {
+			res = icRejected
+		}
+		if res.code != 0 {
+			return nil, []byte(res.stderr), exitError(res.code)
+		}
+		return nil, nil, nil
+	})}
+}
+
+func TestOutbox_QueuesWithoutIC(t *testing.T) {
+	dir := t.TempDir()
+	c := outboxClient(dir, &fakeRunner{})
+
+	c.EmitEvent("run-1", "a.one", `{"event_id":"e1"}`)
+	c.EmitEvent("run-1", "a.one", `{"event_id":"e1"}`) // retry: deduped
+	c.EmitEvent("", "a.two")                           // no run to attach it to: dropped
+	c.EmitEvent("run-1", "a.bad", `{"broken":`)
+	c.EmitEventJSON("run-1", "a.three", map[string]int{"n": 3})
+
+	want := []string{`run-1 a.one {}`, `run-1 a.three {"n":3}`}
+	if got := outboxLines(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("outbox = %q, want %q", got, want)
+	}
+	entries, _ := readOutbox(c.outboxPath())
+	if id := entries[1].ID; id == "" || !strings.Contains(string(entries[1].Payload), `"event_id":"`+id+`"`) {
+		t.Errorf("queued payload %s does not carry its outbox ID %q", entries[1].Payload, id)
+	}
+	if n := c.FlushOutbox(); n != 0 {
+		t.Errorf("FlushOutbox() without ic = %d, want 0", n)
+	}
+}
+
+func TestOutbox_Disabled(t *testing.T) {
+	dir := t.TempDir()
+	c := New(WithEnv(map[string]string{"XDG_CONFIG_HOME": dir}), WithLookPath(onPath()), WithRunner(&fakeRunner{}))
+
+	c.EmitEvent("run-1", "a.one")
+	if _, err := os.Stat(c.outboxPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("outbox file exists with outbox disabled: %v", err)
+	}
+}
+
+func TestOutbox_QueuesOnlyTransientFailures(t *testing.T) {
+	dir := t.TempDir()
+	r := icRunner(map[string]icResult{
+		`ic events emit run-1 a.locked --payload={}`: icLocked,
+	})
+	c := outboxClient(dir, r, "ic")
+
+	c.EmitEvent("run-1", "a.locked")
+	c.EmitEvent("run-1", "a.rejected")
+	if got, want := outboxLines(t, c), []string{"run-1 a.locked {}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("outbox = %q, want %q", got, want)
+	}
+}
+
+func TestFlushOutbox_ReplaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+	offline := outboxClient(dir, &fakeRunner{})
+	offline.EmitEvent("run-1", "a.one", `{"n":1}`)
+	offline.EmitEvent("run-2", "a.two", `{"n":2}`)
+	offline.EmitEvent("run-1", "a.three", `{"n":3}`)
+	queued, _ := readOutbox(offline.outboxPath())
+
+	r := icRunner(map[string]icResult{
+		`ic events emit run-1 a.one --payload={"n":1}`:   {},
+		`ic events emit run-2 a.two --payload={"n":2}`:   {},
+		`ic events emit run-1 a.three --payload={"n":3}`: {},
+	})
+	online := outboxClient(dir, r, "ic")
+	if n := online.FlushOutbox(); n != 3 {
+		t.Errorf("FlushOutbox() = %d, want 3", n)
+	}
+	calls := r.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("runner calls = %q, want 3 emits", r.Commands())
+	}
+	for i, call := range calls {
+		if want := "--payload=" + string(queued[i].Payload); call.Args[len(call.Args)-1] != want {
+			t.Errorf("emit %d sent %s, want the queued payload %s", i, call.Args[len(call.Args)-1], want)
+		}
+	}
+	path := online.outboxPath()
+	for _, p := range []string{path, path + ".flushing", path + ".lock"} {
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s left behind after full flush", filepath.Base(p))
+		}
+	}
+}
+
+func TestFlushOutbox_StopsAtTransientFailure(t *testing.T) {
+	dir := t.TempDir()
+	offline := outboxClient(dir, &fakeRunner{})
+	offline.EmitEvent("run-1", "a.one")
+	offline.EmitEvent("run-1", "a.two")
+	offline.EmitEvent("run-1", "a.three")
+
+	r := icRunner(map[string]icResult{
+		`ic events emit run-1 a.one --payload={}`: {},
+		`ic events emit run-1 a.two --payload={}`: icLocked,
+	})
+	online := outboxClient(dir, r, "ic")
+	if n := online.FlushOutbox(); n != 1 {
+		t.Errorf("FlushOutbox() = %d, want 1", n)
+	}
+	want := []string{"run-1 a.two {}", "run-1 a.three {}"}
+	if got := outboxLines(t, online); !reflect.DeepEqual(got, want) {
+		t.Errorf("outbox after partial flush = %q, want %q", got, want)
+	}
+}
+
+func TestFlushOutbox_FailedReleaseKeepsOnlyUndelivered(t *testing.T) {
+	dir := t.TempDir()
+	offline := outboxClient(dir, &fakeRunner{})
+	offline.EmitEvent("run-1", "a.one")
+	offline.EmitEvent("run-1", "a.two")
+	offline.EmitEvent("run-1", "a.three")
+	path := offline.outboxPath()
+
+	var inflight []string
+	r := icRunner(map[string]icResult{
+		`ic events emit run-1 a.one --payload={}`:   {},
+		`ic events emit run-1 a.two --payload={}`:   {},
+		`ic events emit run-1 a.three --payload={}`: icLocked,
+	})
+	next := r.Next
+	r.Next = RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		if args[3] == "a.three" {
+			inflight = outboxLines(t, offline, filepath.Base(path)+".flushing")
+			os.WriteFile(path+".lock", nil, 0644) // another process holds the lock at release
+		}
+		return next.Run(ctx, name, args...)
+	})
+	online := outboxClient(dir, r, "ic")
+	if n := online.FlushOutbox(); n != 2 {
+		t.Errorf("FlushOutbox() = %d, want 2", n)
+	}
+	want := []string{"run-1 a.three {}"}
+	if !reflect.DeepEqual(inflight, want) {
+		t.Errorf("claim during the flush = %q, want only the undelivered %q", inflight, want)
+	}
+
+	// The claim is adopted once stale: only a.three is replayed.
+	os.Remove(path + ".lock")
+	old := time.Now().Add(-outboxFlushStale - time.Minute)
+	os.Chtimes(path+".flushing", old, old)
+	r.Reset()
+	r.Next = next
+	online.FlushOutbox()
+	if got := r.Commands(); len(got) != 1 || !strings.Contains(got[0], "a.three") {
+		t.Errorf("replay after a failed release = %q, want only a.three", got)
+	}
+}
+
+func TestFlushOutbox_DeadLettersRejected(t *testing.T) {
+	dir := t.TempDir()
+	offline := outboxClient(dir, &fakeRunner{})
+	offline.EmitEvent("run-1", "a.bad")
+	offline.EmitEvent("run-1", "a.good")
+	legacy := outboxEntry{ID: "old", EventType: "a.old", Payload: json.RawMessage(`{}`)} // queued by an older SDK without a run
+	appendFile(offline.outboxPath(), append(mustJSON(t, legacy), '\n'))
+
+	r := icRunner(map[string]icResult{
+		`ic events emit run-1 a.good --payload={}`: {},
+	})
+	online := outboxClient(dir, r, "ic")
+	for i := range 3 {
+		n := online.FlushOutbox()
+		if want := map[int]int{0: 1}[i]; n != want {
+			t.Errorf("FlushOutbox() #%d = %d, want %d", i+1, n, want)
+		}
+	}
+	if got := outboxLines(t, online); len(got) != 0 {
+		t.Errorf("outbox = %q, want empty", got)
+	}
+	want := []string{"run-1 a.bad {}", " a.old {}"}
+	if got := outboxLines(t, online, deadLetterFile); !reflect.DeepEqual(got, want) {
+		t.Errorf("dead letters = %q, want %q", got, want)
+	}
+	dead, _ := readOutbox(filepath.Join(dir, "interverse", deadLetterFile))
+	if len(dead) == 0 || !strings.Contains(dead[0].Error, "unknown event type") {
+		t.Errorf("dead letter %+v does not record ic's error", dead)
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestOutbox_Caps(t *testing.T) {
+	dir := t.TempDir()
+	c := New(
+		WithEnv(map[string]string{"XDG_CONFIG_HOME": dir}),
+		WithLookPath(onPath()),
+		WithOutbox(OutboxConfig{MaxEvents: 2}),
+	)
+	c.EmitEvent("run-1", "a.one")
+	c.EmitEvent("run-1", "a.two")
+	c.EmitEvent("run-1", "a.three")
+
+	want := []string{"run-1 a.one {}", "run-1 a.two {}"}
+	if got := outboxLines(t, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("outbox = %q, want %q (newest dropped at cap)", got, want)
+	}
+
+	small := New(
+		WithEnv(map[string]string{"XDG_CONFIG_HOME": t.TempDir()}),
+		WithLookPath(onPath()),
+		WithOutbox(OutboxConfig{MaxBytes: 64}),
+	)
+	small.EmitEvent("run-1", "a.one", `{"pad":"`+strings.Repeat("x", 64)+`"}`)
+	if got := outboxLines(t, small); len(got) != 0 {
+		t.Errorf("outbox = %q, want empty (over MaxBytes)", got)
+	}
+}
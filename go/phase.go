@@ -0,0 +1,306 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mistakeknot/interbase/go/events"
+)
+
+// Phase is a Clavain sprint phase as stored in a bead's phase= state.
+type Phase string
+
+// Canonical Clavain sprint phases, in sprint order.
+const (
+	PhaseBrainstorm         Phase = "brainstorm"
+	PhaseBrainstormReviewed Phase = "brainstorm-reviewed"
+	PhaseStrategized        Phase = "strategized"
+	PhasePlanned            Phase = "planned"
+	PhasePlanReviewed       Phase = "plan-reviewed"
+	PhaseExecuting          Phase = "executing"
+	PhaseShipping           Phase = "shipping"
+	PhaseDone               Phase = "done"
+)
+
+// Phases returns the canonical phases in sprint order.
+func Phases() []Phase {
+	return []Phase{
+		PhaseBrainstorm,
+		PhaseBrainstormReviewed,
+		PhaseStrategized,
+		PhasePlanned,
+		PhasePlanReviewed,
+		PhaseExecuting,
+		PhaseShipping,
+		PhaseDone,
+	}
+}
+
+// PhaseGraph maps each phase to the phases it may move to. A phase is known
+// to the graph if it appears as a key, even with no outgoing edges.
+type PhaseGraph map[Phase][]Phase
+
+// DefaultPhaseGraph returns the standard sprint flow: forward one step,
+// review steps may be skipped, a failed review returns to its draft phase,
+// and shipping may fall back to executing. done is terminal.
+func DefaultPhaseGraph() PhaseGraph {
+	return PhaseGraph{
+		PhaseBrainstorm:         {PhaseBrainstormReviewed, PhaseStrategized},
+		PhaseBrainstormReviewed: {PhaseBrainstorm, PhaseStrategized},
+		PhaseStrategized:        {PhasePlanned},
+		PhasePlanned:            {PhasePlanReviewed, PhaseExecuting},
+		PhasePlanReviewed:       {PhasePlanned, PhaseExecuting},
+		PhaseExecuting:          {PhaseShipping},
+		PhaseShipping:           {PhaseExecuting, PhaseDone},
+		PhaseDone:               {},
+	}
+}
+
+// Known returns true if p is a phase in the graph.
+func (g PhaseGraph) Known(p Phase) bool {
+	_, ok := g[p]
+	return ok
+}
+
+// Allows returns true if the graph has an edge from -> to.
+func (g PhaseGraph) Allows(from, to Phase) bool {
+	for _, next := range g[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a deep copy so callers cannot mutate a Client's graph.
+func (g PhaseGraph) clone() PhaseGraph {
+	out := make(PhaseGraph, len(g))
+	for from, next := range g {
+		out[from] = append([]Phase{}, next...)
+	}
+	return out
+}
+
+// SetPhaseGraph replaces the transition graph PhaseTransition enforces.
+func SetPhaseGraph(g PhaseGraph) {
+	defaultClient.SetPhaseGraph(g)
+}
+
+// SetPhaseGraph is SetPhaseGraph for c.
+func (c *Client) SetPhaseGraph(g PhaseGraph) {
+	g = g.clone()
+	c.graphMu.Lock()
+	c.graph = g
+	c.graphMu.Unlock()
+}
+
+// CurrentPhaseGraph returns a copy of the enforced transition graph, e.g.
+// for rendering. Defaults to DefaultPhaseGraph.
+func CurrentPhaseGraph() PhaseGraph {
+	return defaultClient.CurrentPhaseGraph()
+}
+
+// CurrentPhaseGraph is CurrentPhaseGraph for c.
+func (c *Client) CurrentPhaseGraph() PhaseGraph {
+	c.graphMu.RLock()
+	defer c.graphMu.RUnlock()
+	if c.graph == nil {
+		return DefaultPhaseGraph()
+	}
+	return c.graph.clone()
+}
+
+// PhaseTransition moves bead from one phase to another via PhaseSet, but
+// only along an edge of the phase graph. Illegal or unknown transitions are
+// logged and skipped (fail-open: nothing is returned).
+func PhaseTransition(bead string, from, to Phase, reason ...string) {
+	defaultClient.PhaseTransition(bead, from, to, reason...)
+}
+
+// PhaseTransition is PhaseTransition for c.
+func (c *Client) PhaseTransition(bead string, from, to Phase, reason ...string) {
+	ctx, cancel := c.context()
+	defer cancel()
+	c.PhaseTransitionContext(ctx, bead, from, to, reason...)
+}
+
+// PhaseTransitionContext is PhaseTransition bounded by ctx.
+func PhaseTransitionContext(ctx context.Context, bead string, from, to Phase, reason ...string) {
+	defaultClient.PhaseTransitionContext(ctx, bead, from, to, reason...)
+}
+
+// PhaseTransitionContext is PhaseTransitionContext for c.
+func (c *Client) PhaseTransitionContext(ctx context.Context, bead string, from, to Phase, reason ...string) {
+	c.logFailure(c.PhaseTransitionContextE(ctx, bead, from, to, reason...), "bead", bead, "from", string(from), "to", string(to))
+}
+
+// PhaseTransitionE is PhaseTransition returning the error: a refused
+// transition, or whatever PhaseSetE reports.
+func PhaseTransitionE(bead string, from, to Phase, reason ...string) error {
+	return defaultClient.PhaseTransitionE(bead, from, to, reason...)
+}
+
+// PhaseTransitionE is PhaseTransitionE for c.
+func (c *Client) PhaseTransitionE(bead string, from, to Phase, reason ...string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.PhaseTransitionContextE(ctx, bead, from, to, reason...)
+}
+
+// PhaseTransitionContextE is PhaseTransitionE bounded by ctx.
+func PhaseTransitionContextE(ctx context.Context, bead string, from, to Phase, reason ...string) error {
+	return defaultClient.PhaseTransitionContextE(ctx, bead, from, to, reason...)
+}
+
+// PhaseTransitionContextE is PhaseTransitionContextE for c.
+func (c *Client) PhaseTransitionContextE(ctx context.Context, bead string, from, to Phase, reason ...string) error {
+	if err := c.checkTransition(from, to); err != nil {
+		return fmt.Errorf("phase transition refused for %s: %w", bead, err)
+	}
+	return c.PhaseSetContextE(ctx, bead, string(to), reason...)
+}
+
+func (c *Client) checkTransition(from, to Phase) error {
+	g := c.CurrentPhaseGraph()
+	switch {
+	case !g.Known(from):
+		return fmt.Errorf("unknown phase %q", from)
+	case !g.Known(to):
+		return fmt.Errorf("unknown phase %q", to)
+	case !g.Allows(from, to):
+		return fmt.Errorf("illegal transition %s -> %s", from, to)
+	}
+	return nil
+}
+
+// phaseChanged is the payload of the phase.changed event emitted by PhaseSet.
+type phaseChanged struct {
+	Bead   string `json:"bead"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PhaseChangedEvent is the event type PhaseSet emits. Its payload schema is
+// registered in events.Default, so hand-built phase.changed events are held
+// to the same shape.
+const PhaseChangedEvent = "phase.changed"
+
+func init() {
+	events.MustRegister(PhaseChangedEvent, `{
+		"type": "object",
+		"required": ["bead", "from", "to"],
+		"properties": {
+			"bead":   {"type": "string", "minLength": 1},
+			"from":   {"type": "string"},
+			"to":     {"type": "string", "minLength": 1},
+			"reason": {"type": "string"}
+		}
+	}`)
+}
+
+// PhaseGet returns the current phase of bead via `bd state BEAD phase`.
+// Returns "" if bd is missing, fails, or the bead has no phase yet.
+func PhaseGet(bead string) Phase {
+	return defaultClient.PhaseGet(bead)
+}
+
+// PhaseGet is PhaseGet for c.
+func (c *Client) PhaseGet(bead string) Phase {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.PhaseGetContext(ctx, bead)
+}
+
+// PhaseGetContext is PhaseGet bounded by ctx.
+func PhaseGetContext(ctx context.Context, bead string) Phase {
+	return defaultClient.PhaseGetContext(ctx, bead)
+}
+
+// PhaseGetContext is PhaseGetContext for c.
+func (c *Client) PhaseGetContext(ctx context.Context, bead string) Phase {
+	return Phase(c.BeadStateGetContext(ctx, bead, "phase"))
+}
+
+// PhaseChange is one entry of a bead's phase history.
+type PhaseChange struct {
+	Phase  Phase     `json:"phase"`
+	Reason string    `json:"reason,omitempty"`
+	Actor  string    `json:"actor,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// PhaseHistory returns the recorded phase changes of bead, oldest first, via
+// `bd state BEAD phase --history --json`. Returns nil if bd is missing,
+// fails, or prints something other than a JSON array.
+func PhaseHistory(bead string) []PhaseChange {
+	return defaultClient.PhaseHistory(bead)
+}
+
+// PhaseHistory is PhaseHistory for c.
+func (c *Client) PhaseHistory(bead string) []PhaseChange {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.PhaseHistoryContext(ctx, bead)
+}
+
+// PhaseHistoryContext is PhaseHistory bounded by ctx.
+func PhaseHistoryContext(ctx context.Context, bead string) []PhaseChange {
+	return defaultClient.PhaseHistoryContext(ctx, bead)
+}
+
+// PhaseHistoryContext is PhaseHistoryContext for c.
+func (c *Client) PhaseHistoryContext(ctx context.Context, bead string) []PhaseChange {
+	if bead == "" || !c.HasBD() {
+		return nil
+	}
+	out, _, err := c.run(ctx, "bd", "state", bead, "phase", "--history", "--json")
+	if err != nil {
+		return nil
+	}
+	return parsePhaseHistory(out)
+}
+
+// phaseHistoryWire accepts the field spellings bd uses for state events.
+type phaseHistoryWire struct {
+	Value     string          `json:"value"`
+	Phase     string          `json:"phase"`
+	Reason    string          `json:"reason"`
+	Actor     string          `json:"actor"`
+	At        json.RawMessage `json:"at"`
+	CreatedAt json.RawMessage `json:"created_at"`
+}
+
+func parsePhaseHistory(out []byte) []PhaseChange {
+	var wire []phaseHistoryWire
+	if err := json.Unmarshal(out, &wire); err != nil {
+		return nil
+	}
+	changes := make([]PhaseChange, 0, len(wire))
+	for _, w := range wire {
+		pc := PhaseChange{
+			Phase:  Phase(firstNonEmpty(w.Value, w.Phase)),
+			Reason: w.Reason,
+			Actor:  w.Actor,
+			At:     parseWireTime(w.At),
+		}
+		if pc.At.IsZero() {
+			pc.At = parseWireTime(w.CreatedAt)
+		}
+		if pc.Phase != "" {
+			changes = append(changes, pc)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].At.Before(changes[j].At)
+	})
+	return changes
+}
+
+// emitPhaseChanged records a phase transition on ic run runID.
+func (c *Client) emitPhaseChanged(ctx context.Context, runID string, ev phaseChanged) {
+	c.EmitEventJSONContext(ctx, runID, PhaseChangedEvent, ev)
+}
@@ -0,0 +1,163 @@
+package interbase
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPhaseSet_RecordsReasonAndEmits(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd state iv-1 phase": {stdout: "planned\n"},
+		"bd set-state iv-1 phase=executing --reason plan approved": {},
+		"ic run current --project=.":                               {stdout: "run-1"},
+		`ic events emit run-1 phase.changed --payload={"bead":"iv-1","from":"planned","to":"executing","reason":"plan approved"}`: {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd", "ic")), WithRunner(r))
+
+	c.PhaseSet("iv-1", "executing", "plan approved")
+	want := []string{
+		"ic run current --project=.",
+		"bd state iv-1 phase",
+		"bd set-state iv-1 phase=executing --reason plan approved",
+		`ic events emit run-1 phase.changed --payload={"bead":"iv-1","from":"planned","to":"executing","reason":"plan approved"}`,
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls =\n%q\nwant\n%q", r.calls, want)
+	}
+}
+
+func TestPhaseSet_NoICSkipsAudit(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd set-state iv-1 phase=planned": {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	c.PhaseSet("iv-1", "planned")
+	want := []string{"bd set-state iv-1 phase=planned"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestPhaseSet_NoRunSkipsAudit(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.":      {err: errors.New("exit status 1")},
+		"bd set-state iv-1 phase=planned": {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd", "ic")), WithRunner(r))
+
+	c.PhaseSet("iv-1", "planned")
+	want := []string{"ic run current --project=.", "bd set-state iv-1 phase=planned"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want no phase read or event without an active run", r.calls)
+	}
+}
+
+func TestPhaseSet_FailedSetSkipsEvent(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.":      {stdout: "run-1"},
+		"bd state iv-1 phase":             {},
+		"bd set-state iv-1 phase=planned": {err: errors.New("exit status 1")},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd", "ic")), WithRunner(r))
+
+	c.PhaseSet("iv-1", "planned")
+	if len(r.calls) != 3 {
+		t.Errorf("runner calls = %q, want no event after failed set-state", r.calls)
+	}
+}
+
+func TestDefaultPhaseGraph_CoversCanonicalPhases(t *testing.T) {
+	g := DefaultPhaseGraph()
+	for _, p := range Phases() {
+		if !g.Known(p) {
+			t.Errorf("DefaultPhaseGraph missing phase %q", p)
+		}
+	}
+	for from, next := range g {
+		for _, to := range next {
+			if !g.Known(to) {
+				t.Errorf("edge %s -> %s targets unknown phase", from, to)
+			}
+		}
+	}
+}
+
+func TestPhaseTransition(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd set-state iv-1 phase=executing": {},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	c.PhaseTransition("iv-1", PhasePlanned, PhaseExecuting)
+	c.PhaseTransition("iv-1", PhaseBrainstorm, PhaseDone)
+	c.PhaseTransition("iv-1", PhasePlanned, Phase("planed"))
+	want := []string{"bd set-state iv-1 phase=executing"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want only the legal transition", r.calls)
+	}
+}
+
+func TestPhaseTransition_CustomGraph(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd set-state iv-1 phase=triaged": {},
+	}}
+	c := New(
+		WithEnv(map[string]string{}),
+		WithLookPath(onPath("bd")),
+		WithRunner(r),
+		WithPhaseGraph(PhaseGraph{"new": {"triaged"}, "triaged": {}}),
+	)
+
+	c.PhaseTransition("iv-1", "new", "triaged")
+	c.PhaseTransition("iv-1", PhasePlanned, PhaseExecuting)
+	if len(r.calls) != 1 {
+		t.Errorf("runner calls = %q, want only the custom-graph transition", r.calls)
+	}
+
+	g := c.CurrentPhaseGraph()
+	g["new"] = append(g["new"], "done")
+	if c.CurrentPhaseGraph().Allows("new", "done") {
+		t.Error("mutating CurrentPhaseGraph() result changed the client's graph")
+	}
+}
+
+func TestPhaseGet(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd state iv-1 phase": {stdout: "plan-reviewed\n"},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+	if got := c.PhaseGet("iv-1"); got != PhasePlanReviewed {
+		t.Errorf("PhaseGet() = %q, want %q", got, PhasePlanReviewed)
+	}
+
+	missing := New(WithEnv(map[string]string{}), WithLookPath(onPath()), WithRunner(r))
+	if got := missing.PhaseGet("iv-1"); got != "" {
+		t.Errorf("PhaseGet() without bd = %q, want empty", got)
+	}
+}
+
+func TestPhaseHistory(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"bd state iv-1 phase --history --json": {stdout: `[
+			{"value":"planned","reason":"strategy done","actor":"agent-x","created_at":"2026-03-02T09:00:00Z"},
+			{"value":"strategized","created_at":"2026-03-01T09:00:00Z"}
+		]`},
+		"bd state iv-2 phase --history --json": {stdout: "no history"},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	got := c.PhaseHistory("iv-1")
+	want := []PhaseChange{
+		{Phase: PhaseStrategized, At: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)},
+		{Phase: PhasePlanned, Reason: "strategy done", Actor: "agent-x", At: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PhaseHistory() = %+v, want %+v", got, want)
+	}
+	if got := c.PhaseHistory("iv-2"); got != nil {
+		t.Errorf("PhaseHistory() for non-JSON output = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,109 @@
+package interbase
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// PluginVersion is one installed copy of a plugin in the Claude Code cache
+// (~/.claude/plugins/cache/MARKETPLACE/PLUGIN/VERSION).
+type PluginVersion struct {
+	Marketplace string `json:"marketplace"`
+	Version     string `json:"version"`
+	Path        string `json:"path"`
+}
+
+// PluginVersions lists every installed version of plugin across all
+// marketplaces, newest first. Versions are ordered by semver precedence, so
+// 1.10.0 sorts above 1.9.0; the same version in several marketplaces is
+// ordered by marketplace name. Directory names that are not versions sort
+// after all versions. Returns nil if the plugin is not installed.
+func PluginVersions(plugin string) []PluginVersion {
+	return defaultClient.PluginVersions(plugin)
+}
+
+// PluginVersions is PluginVersions for c.
+func (c *Client) PluginVersions(plugin string) []PluginVersion {
+	if plugin == "" {
+		return nil
+	}
+	root := c.pluginCacheDir()
+	if root == "" {
+		return nil
+	}
+	matches, err := c.glob(filepath.Join(root, "*", plugin, "*"))
+	if err != nil || len(matches) == 0 {
+		return nil
+	}
+
+	type entry struct {
+		pv     PluginVersion
+		v      version
+		semver bool
+	}
+	entries := make([]entry, 0, len(matches))
+	for _, m := range matches {
+		name := filepath.Base(m)
+		v, ok := parseVersion(name)
+		entries = append(entries, entry{
+			pv: PluginVersion{
+				Marketplace: filepath.Base(filepath.Dir(filepath.Dir(m))),
+				Version:     name,
+				Path:        m,
+			},
+			v:      v,
+			semver: ok,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.semver != b.semver {
+			return a.semver
+		}
+		if a.semver {
+			if cmp := a.v.compare(b.v); cmp != 0 {
+				return cmp > 0
+			}
+		} else if a.pv.Version != b.pv.Version {
+			return a.pv.Version > b.pv.Version
+		}
+		return a.pv.Marketplace < b.pv.Marketplace
+	})
+
+	out := make([]PluginVersion, len(entries))
+	for i, e := range entries {
+		out[i] = e.pv
+	}
+	return out
+}
+
+// HasCompanionVersion returns true if any installed copy of the named plugin
+// satisfies constraint, e.g. ">=1.2", "^1.2.0", "~1.4" or "1.2 - 1.4".
+// Non-semver cache directories never match. Returns false if name is empty,
+// the plugin is missing, or the constraint is invalid.
+func HasCompanionVersion(name, constraint string) bool {
+	return defaultClient.HasCompanionVersion(name, constraint)
+}
+
+// HasCompanionVersion is HasCompanionVersion for c.
+func (c *Client) HasCompanionVersion(name, constraint string) bool {
+	want, ok := parseConstraint(constraint)
+	if !ok {
+		return false
+	}
+	for _, pv := range c.PluginVersions(name) {
+		if v, ok := parseVersion(pv.Version); ok && want.matches(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginCacheDir returns ~/.claude/plugins/cache, or empty if HOME is unknown.
+func (c *Client) pluginCacheDir() string {
+	home, err := c.homeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude", "plugins", "cache")
+}
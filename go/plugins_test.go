@@ -0,0 +1,94 @@
+package interbase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makePluginCache creates cache dirs under a temp HOME for each
+// "marketplace/plugin/version" entry and returns the cache root.
+func makePluginCache(t *testing.T, entries ...string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := filepath.Join(home, ".claude", "plugins", "cache")
+	for _, e := range entries {
+		if err := os.MkdirAll(filepath.Join(root, e), 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", e, err)
+		}
+	}
+	return root
+}
+
+func TestPluginVersions_SemverOrder(t *testing.T) {
+	root := makePluginCache(t,
+		"mp-a/interflux/1.9.0",
+		"mp-a/interflux/1.10.0",
+		"mp-b/interflux/1.10.0",
+		"mp-b/interflux/1.2.0-rc.1",
+		"mp-b/interflux/1.2.0",
+		"mp-a/interflux/latest",
+		"mp-a/other/9.9.9",
+	)
+
+	got := PluginVersions("interflux")
+	want := []PluginVersion{
+		{"mp-a", "1.10.0", filepath.Join(root, "mp-a/interflux/1.10.0")},
+		{"mp-b", "1.10.0", filepath.Join(root, "mp-b/interflux/1.10.0")},
+		{"mp-a", "1.9.0", filepath.Join(root, "mp-a/interflux/1.9.0")},
+		{"mp-b", "1.2.0", filepath.Join(root, "mp-b/interflux/1.2.0")},
+		{"mp-b", "1.2.0-rc.1", filepath.Join(root, "mp-b/interflux/1.2.0-rc.1")},
+		{"mp-a", "latest", filepath.Join(root, "mp-a/interflux/latest")},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PluginVersions() returned %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("PluginVersions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPluginCachePath_HighestSemver(t *testing.T) {
+	root := makePluginCache(t, "mp-z/interflux/1.9.0", "mp-a/interflux/1.10.0")
+	want := filepath.Join(root, "mp-a", "interflux", "1.10.0")
+	if got := PluginCachePath("interflux"); got != want {
+		t.Errorf("PluginCachePath() = %q, want %q", got, want)
+	}
+}
+
+func TestPluginVersions_Missing(t *testing.T) {
+	makePluginCache(t)
+	if got := PluginVersions("interflux"); got != nil {
+		t.Errorf("PluginVersions() = %+v, want nil", got)
+	}
+	if got := PluginCachePath("interflux"); got != "" {
+		t.Errorf("PluginCachePath() = %q, want empty", got)
+	}
+}
+
+func TestHasCompanionVersion(t *testing.T) {
+	makePluginCache(t, "mp-a/interflux/1.1.0", "mp-b/interflux/1.4.2", "mp-a/interlock/dev")
+
+	tests := []struct {
+		name, constraint string
+		want             bool
+	}{
+		{"interflux", ">=1.2", true},
+		{"interflux", "^1.4", true},
+		{"interflux", "~1.1", true},
+		{"interflux", "1.2 - 1.3", false},
+		{"interflux", ">=2", false},
+		{"interflux", "not-a-range", false},
+		{"interlock", "*", false},
+		{"missing", "*", false},
+		{"", "*", false},
+	}
+	for _, tt := range tests {
+		if got := HasCompanionVersion(tt.name, tt.constraint); got != tt.want {
+			t.Errorf("HasCompanionVersion(%q, %q) = %v, want %v", tt.name, tt.constraint, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package interbase
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultProbeTTL is how long guard probe results are reused before the
+// underlying PATH lookup or ic subprocess runs again.
+const DefaultProbeTTL = 30 * time.Second
+
+// SetProbeTTL sets how long cached probe results stay valid.
+// A TTL of zero or less disables caching.
+func SetProbeTTL(ttl time.Duration) {
+	defaultClient.SetProbeTTL(ttl)
+}
+
+// SetProbeTTL is SetProbeTTL for c.
+func (c *Client) SetProbeTTL(ttl time.Duration) {
+	c.probes.setTTL(ttl)
+}
+
+// Refresh discards all cached probe results so the next guard call re-probes.
+func Refresh() {
+	defaultClient.Refresh()
+}
+
+// Refresh is Refresh for c.
+func (c *Client) Refresh() {
+	c.probes.reset()
+}
+
+// probeResult is a single cached probe outcome.
+type probeResult struct {
+	out string
+	ok  bool
+	at  time.Time
+}
+
+// probeCache memoizes probe outcomes for a bounded time. Keys include PATH
+// (and CWD for ic run probes) so changing either re-probes without an
+// explicit Refresh.
+type probeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]probeResult
+}
+
+func newProbeCache(ttl time.Duration) *probeCache {
+	return &probeCache{ttl: ttl, now: time.Now, entries: make(map[string]probeResult)}
+}
+
+func (p *probeCache) setTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ttl = ttl
+	if ttl <= 0 {
+		p.entries = make(map[string]probeResult)
+	}
+}
+
+func (p *probeCache) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = make(map[string]probeResult)
+}
+
+// do returns the cached result for key, or runs fn and caches its result.
+// fn runs without the lock held, so concurrent misses may probe twice.
+// Results produced after ctx is done are not cached: a timeout says nothing
+// about the probed tool.
+func (p *probeCache) do(ctx context.Context, key string, fn func() (string, bool)) (string, bool) {
+	p.mu.Lock()
+	ttl := p.ttl
+	if r, ok := p.entries[key]; ok && p.now().Sub(r.at) < ttl {
+		p.mu.Unlock()
+		return r.out, r.ok
+	}
+	p.mu.Unlock()
+
+	out, ok := fn()
+	if ttl > 0 && ctx.Err() == nil {
+		p.mu.Lock()
+		p.entries[key] = probeResult{out: out, ok: ok, at: p.now()}
+		p.mu.Unlock()
+	}
+	return out, ok
+}
+
+// hasBinary reports whether name is available: served by the Runner (see
+// AvailableRunner) or resolved on PATH, via the probe cache.
+func (c *Client) hasBinary(name string) bool {
+	if r, ok := c.runner.Load().Runner.(AvailableRunner); ok && r.Available(name) {
+		return true
+	}
+	key := "lookpath\x00" + name + "\x00" + c.getenv("PATH")
+	_, ok := c.probes.do(context.Background(), key, func() (string, bool) {
+		path, err := c.lookPath(name)
+		return path, err == nil
+	})
+	return ok
+}
+
+// icRunActive reports whether `ic run current --project=.` succeeds in the
+// current directory, via the probe cache. Callers must check HasIC first.
+func (c *Client) icRunActive(ctx context.Context) bool {
+	_, ok := c.icRunCurrent(ctx)
+	return ok
+}
+
+// icRunCurrent returns the stdout of `ic run current --project=.` and whether
+// it succeeded, via the probe cache. Callers must check HasIC first.
+func (c *Client) icRunCurrent(ctx context.Context) (string, bool) {
+	cwd, _ := os.Getwd()
+	key := "ic-run-current\x00" + cwd + "\x00" + c.getenv("PATH")
+	return c.probes.do(ctx, key, func() (string, bool) {
+		out, _, err := c.run(ctx, "ic", "run", "current", "--project=.")
+		return string(out), err == nil
+	})
+}
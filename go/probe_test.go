@@ -0,0 +1,89 @@
+package interbase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeBin writes an executable shell script named name into dir.
+func writeFakeBin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("write fake %s: %v", name, err)
+	}
+	return path
+}
+
+func TestHasIC_Cached(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	path := writeFakeBin(t, dir, "ic", "exit 0")
+	t.Setenv("PATH", dir)
+
+	if !HasIC() {
+		t.Fatal("HasIC() = false, want true (fake ic on PATH)")
+	}
+	os.Remove(path)
+	if !HasIC() {
+		t.Error("HasIC() = false after removal, want cached true")
+	}
+	Refresh()
+	if HasIC() {
+		t.Error("HasIC() = true after Refresh, want false")
+	}
+}
+
+func TestHasIC_TTLDisabled(t *testing.T) {
+	Refresh()
+	SetProbeTTL(0)
+	t.Cleanup(func() { SetProbeTTL(DefaultProbeTTL) })
+	dir := t.TempDir()
+	path := writeFakeBin(t, dir, "ic", "exit 0")
+	t.Setenv("PATH", dir)
+
+	if !HasIC() {
+		t.Fatal("HasIC() = false, want true (fake ic on PATH)")
+	}
+	os.Remove(path)
+	if HasIC() {
+		t.Error("HasIC() = true with caching disabled, want false")
+	}
+}
+
+func TestInSprint_CachesRunProbe(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	writeFakeBin(t, dir, "ic", `echo "$@" >> "`+log+`"`)
+	t.Setenv("PATH", dir)
+	t.Setenv("CLAVAIN_BEAD_ID", "iv-test")
+
+	for i := 0; i < 3; i++ {
+		if !InSprint() {
+			t.Fatalf("InSprint() call %d = false, want true", i)
+		}
+	}
+	data, _ := os.ReadFile(log)
+	if n := strings.Count(string(data), "run current"); n != 1 {
+		t.Errorf("ic run current invoked %d times, want 1", n)
+	}
+}
+
+func TestProbeCache_Expires(t *testing.T) {
+	p := newProbeCache(time.Millisecond)
+	calls := 0
+	fn := func() (string, bool) { calls++; return "", true }
+	p.do(context.Background(), "k", fn)
+	time.Sleep(5 * time.Millisecond)
+	p.do(context.Background(), "k", fn)
+	if calls != 2 {
+		t.Errorf("probe ran %d times, want 2 after TTL expiry", calls)
+	}
+}
@@ -0,0 +1,134 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mistakeknot/interbase/go/toolerror"
+)
+
+// Retry defaults applied when RetryPolicy leaves a field zero.
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = 50 * time.Millisecond
+	DefaultRetryMaxDelay  = time.Second
+)
+
+// Failures classified as transient unless RetryPolicy overrides them: SQLite
+// lock contention in ic or bd, and EX_TEMPFAIL.
+var (
+	defaultTransientStderr    = []string{"database is locked", "database table is locked", "sqlite_busy", "resource temporarily unavailable"}
+	defaultTransientExitCodes = []int{75}
+)
+
+// RetryPolicy retries ic and bd calls made by actions (PhaseSet, EmitEvent,
+// BeadStateSet, FlushOutbox, ...) when they fail transiently. Guards and
+// reads are never retried. Zero fields use the defaults; a nil slice uses the
+// default patterns, an empty one disables that test.
+type RetryPolicy struct {
+	MaxAttempts int           // tries including the first (DefaultRetryAttempts)
+	BaseDelay   time.Duration // backoff before the second try (DefaultRetryBaseDelay)
+	MaxDelay    time.Duration // backoff cap (DefaultRetryMaxDelay)
+	Stderr      []string      // case-insensitive stderr substrings marking a transient failure
+	ExitCodes   []int         // exit codes marking a transient failure
+}
+
+// EnableRetry makes actions retry failures classified as transient (see
+// CommandError.Type) with jittered exponential backoff. Retries share the
+// call's context: no retry starts after it is done, so the whole call still
+// ends by DefaultTimeout or the ctx deadline. Off by default.
+func EnableRetry(p RetryPolicy) {
+	defaultClient.EnableRetry(p)
+}
+
+// EnableRetry is EnableRetry for c.
+func (c *Client) EnableRetry(p RetryPolicy) {
+	p = p.withDefaults()
+	c.retry.Store(&p)
+}
+
+// DisableRetry makes every action try once again.
+func DisableRetry() {
+	defaultClient.DisableRetry()
+}
+
+// DisableRetry is DisableRetry for c.
+func (c *Client) DisableRetry() {
+	c.retry.Store(nil)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryMaxDelay
+	}
+	if p.Stderr == nil {
+		p.Stderr = defaultTransientStderr
+	}
+	if p.ExitCodes == nil {
+		p.ExitCodes = defaultTransientExitCodes
+	}
+	return p
+}
+
+// classify returns the toolerror type of a failed call: ErrTransient for a
+// timeout or a stderr/exit code the policy lists, ErrNotFound for a missing
+// tool, ErrInternal otherwise.
+func (p RetryPolicy) classify(e *CommandError) string {
+	switch {
+	case errors.Is(e.Err, context.DeadlineExceeded):
+		return toolerror.ErrTransient
+	case errors.Is(e.Err, exec.ErrNotFound):
+		return toolerror.ErrNotFound
+	case e.ExitCode >= 0 && slices.Contains(p.ExitCodes, e.ExitCode):
+		return toolerror.ErrTransient
+	}
+	stderr := strings.ToLower(string(e.Stderr))
+	for _, s := range p.Stderr {
+		if s != "" && strings.Contains(stderr, strings.ToLower(s)) {
+			return toolerror.ErrTransient
+		}
+	}
+	return toolerror.ErrInternal
+}
+
+// backoff is the wait after the given failed attempt: exponential from
+// BaseDelay, capped at MaxDelay, with jitter over its upper half.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MaxDelay
+	if attempt < 32 && p.BaseDelay<<(attempt-1) < p.MaxDelay {
+		d = p.BaseDelay << (attempt - 1)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryPolicy is c's policy for classification; retries also need enabled.
+func (c *Client) retryPolicy() (p RetryPolicy, enabled bool) {
+	if rp := c.retry.Load(); rp != nil {
+		return *rp, true
+	}
+	return RetryPolicy{}.withDefaults(), false
+}
+
+// sleepCtx waits d or until ctx is done, reporting whether the full wait
+// elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,114 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mistakeknot/interbase/go/toolerror"
+)
+
+// exitError is a runner error carrying an exit code, like *exec.ExitError.
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+// flakyRunner fails its first n calls with stderr and code, then succeeds.
+func flakyRunner(n int32, stderr string, code int) (Runner, *atomic.Int32) {
+	var calls atomic.Int32
+	return RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		if calls.Add(1) <= n {
+			return nil, []byte(stderr), exitError(code)
+		}
+		return nil, nil, nil
+	}), &calls
+}
+
+func TestRetry_TransientThenSuccess(t *testing.T) {
+	r, calls := flakyRunner(2, "Error: database is locked", 1)
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r),
+		WithRetry(RetryPolicy{BaseDelay: time.Millisecond}))
+
+	if err := c.PhaseSetE("iv-1", "executing"); err != nil {
+		t.Fatalf("PhaseSetE = %v, want success on the third try", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		stderr    string
+		code      int
+		wantCalls int32
+		wantType  string
+	}{
+		{"permanent", "no such bead", 1, 1, toolerror.ErrInternal},
+		{"exit code", "", 75, 4, toolerror.ErrTransient},
+		{"max attempts", "SQLITE_BUSY", 1, 4, toolerror.ErrTransient},
+	}
+	for _, tt := range tests {
+		r, calls := flakyRunner(100, tt.stderr, tt.code)
+		c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r),
+			WithRetry(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}))
+
+		err := c.PhaseSetE("iv-1", "executing")
+		var ce *CommandError
+		if !errors.As(err, &ce) || ce.Type != tt.wantType {
+			t.Errorf("%s: PhaseSetE = %v, want *CommandError of type %s", tt.name, err, tt.wantType)
+		}
+		if n := calls.Load(); n != tt.wantCalls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, n, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetry_BoundedByContext(t *testing.T) {
+	r, calls := flakyRunner(100, "database is locked", 1)
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r),
+		WithRetry(RetryPolicy{MaxAttempts: 100, BaseDelay: 20 * time.Millisecond, MaxDelay: 20 * time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.EmitEventContextE(ctx, "run-1", "a.one")
+	if !errors.Is(err, ErrTransient) {
+		t.Errorf("EmitEventContextE = %v, want ErrTransient", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("retries ran %v past a 50ms context", d)
+	}
+	if n := calls.Load(); n < 2 || n > 6 {
+		t.Errorf("calls = %d, want a few tries within the deadline", n)
+	}
+}
+
+func TestRetry_OffByDefault(t *testing.T) {
+	r, calls := flakyRunner(1, "database is locked", 1)
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	err := c.PhaseSetE("iv-1", "executing")
+	if !errors.Is(err, ErrTransient) {
+		t.Errorf("PhaseSetE = %v, want a failure classified ErrTransient", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1 without EnableRetry", n)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond}.withDefaults()
+	for attempt, max := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 40: 40 * time.Millisecond} {
+		for range 20 {
+			if d := p.backoff(attempt); d < max/2 || d > max {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, d, max/2, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package interbase
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Run is the active intercore run for the current project, as reported by
+// `ic run current --project=.`.
+type Run struct {
+	ID        string    `json:"id"`
+	Project   string    `json:"project"`
+	Phase     string    `json:"phase"`
+	StartedAt time.Time `json:"started_at"`
+	Bead      string    `json:"bead"`
+}
+
+// CurrentRun returns the active ic run. Returns false if ic is missing, there
+// is no active run, or its output cannot be parsed. Shares the cached probe
+// used by InSprint, so calling both costs one subprocess.
+func CurrentRun() (Run, bool) {
+	return defaultClient.CurrentRun()
+}
+
+// CurrentRun is CurrentRun for c.
+func (c *Client) CurrentRun() (Run, bool) {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.CurrentRunContext(ctx)
+}
+
+// CurrentRunContext is CurrentRun bounded by ctx.
+func CurrentRunContext(ctx context.Context) (Run, bool) {
+	return defaultClient.CurrentRunContext(ctx)
+}
+
+// CurrentRunContext is CurrentRunContext for c.
+func (c *Client) CurrentRunContext(ctx context.Context) (Run, bool) {
+	if !c.HasIC() {
+		return Run{}, false
+	}
+	out, ok := c.icRunCurrent(ctx)
+	if !ok {
+		return Run{}, false
+	}
+	run, ok := parseRun(out)
+	if !ok {
+		return Run{}, false
+	}
+	if run.Bead == "" {
+		run.Bead = c.GetBead()
+	}
+	return run, true
+}
+
+// runWire accepts the field spellings ic has used for run records.
+type runWire struct {
+	ID        string          `json:"id"`
+	RunID     string          `json:"run_id"`
+	Project   string          `json:"project"`
+	Phase     string          `json:"phase"`
+	StartedAt json.RawMessage `json:"started_at"`
+	CreatedAt json.RawMessage `json:"created_at"`
+	Bead      string          `json:"bead"`
+	BeadID    string          `json:"bead_id"`
+}
+
+// parseRun decodes ic's JSON run record. Older ic builds print only the run
+// ID, so a single bare token is accepted as the ID.
+func parseRun(out string) (Run, bool) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return Run{}, false
+	}
+	if !strings.HasPrefix(out, "{") {
+		if strings.ContainsAny(out, " \t\n") {
+			return Run{}, false
+		}
+		return Run{ID: out}, true
+	}
+	var w runWire
+	if err := json.Unmarshal([]byte(out), &w); err != nil {
+		return Run{}, false
+	}
+	run := Run{
+		ID:      firstNonEmpty(w.ID, w.RunID),
+		Project: w.Project,
+		Phase:   w.Phase,
+		Bead:    firstNonEmpty(w.Bead, w.BeadID),
+	}
+	if run.ID == "" {
+		return Run{}, false
+	}
+	run.StartedAt = parseWireTime(w.StartedAt)
+	if run.StartedAt.IsZero() {
+		run.StartedAt = parseWireTime(w.CreatedAt)
+	}
+	return run, true
+}
+
+// parseWireTime accepts an RFC 3339 string or Unix seconds; anything else is
+// the zero time.
+func parseWireTime(raw json.RawMessage) time.Time {
+	if len(raw) == 0 {
+		return time.Time{}
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	if secs, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC()
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// defaultRunID resolves the run used by EmitEvent when runID is empty. It
+// shares the `ic run current` probe with InSprint and CurrentRun, so the
+// answer is cached per working directory for the probe TTL and a long-lived
+// process follows the sprints that start and end under it. Returns "" if
+// there is no active run.
+func (c *Client) defaultRunID(ctx context.Context) string {
+	run, _ := c.CurrentRunContext(ctx)
+	return run.ID
+}
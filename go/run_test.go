@@ -0,0 +1,170 @@
+package interbase
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRun(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want Run
+		ok   bool
+	}{
+		{
+			name: "json",
+			out:  `{"id":"run-7","project":"/src/demo","phase":"executing","started_at":"2026-03-01T10:00:00Z","bead_id":"iv-9"}`,
+			want: Run{ID: "run-7", Project: "/src/demo", Phase: "executing", StartedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), Bead: "iv-9"},
+			ok:   true,
+		},
+		{
+			name: "unix created_at",
+			out:  `{"run_id":"run-8","created_at":1772359200}`,
+			want: Run{ID: "run-8", StartedAt: time.Unix(1772359200, 0).UTC()},
+			ok:   true,
+		},
+		{name: "bare id", out: "run-9\n", want: Run{ID: "run-9"}, ok: true},
+		{name: "prose", out: "no active run", ok: false},
+		{name: "json without id", out: `{"phase":"planned"}`, ok: false},
+		{name: "empty", out: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRun(tt.out)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: parseRun() = %+v, %v; want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCurrentRun_SharesProbe(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.": {stdout: `{"id":"run-1","phase":"planned"}`},
+	}}
+	c := New(
+		WithEnv(map[string]string{"CLAVAIN_BEAD_ID": "iv-a"}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+	)
+
+	if !c.InSprint() {
+		t.Fatal("InSprint() = false, want true")
+	}
+	run, ok := c.CurrentRun()
+	if !ok {
+		t.Fatal("CurrentRun() ok = false, want true")
+	}
+	want := Run{ID: "run-1", Phase: "planned", Bead: "iv-a"}
+	if run != want {
+		t.Errorf("CurrentRun() = %+v, want %+v", run, want)
+	}
+	if len(r.calls) != 1 {
+		t.Errorf("ic invoked %d times, want 1 shared probe", len(r.calls))
+	}
+}
+
+func TestCurrentRun_NoIC(t *testing.T) {
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath()))
+	if run, ok := c.CurrentRun(); ok {
+		t.Errorf("CurrentRun() = %+v, true; want false without ic", run)
+	}
+}
+
+func TestEmitEvent_DefaultsToCurrentRun(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.":                   {stdout: "run-5"},
+		"ic events emit run-5 hook.fired --payload={}": {},
+	}}
+	c := New(
+		WithEnv(map[string]string{}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+	)
+
+	c.EmitEvent("", "hook.fired")
+	c.EmitEvent("", "hook.fired")
+	want := []string{
+		"ic run current --project=.",
+		"ic events emit run-5 hook.fired --payload={}",
+		"ic events emit run-5 hook.fired --payload={}",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("runner calls = %q, want %q", r.calls, want)
+	}
+}
+
+func TestEmitEvent_NoActiveRun(t *testing.T) {
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.": {err: errors.New("exit status 1")},
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")), WithRunner(r))
+
+	c.EmitEvent("", "hook.fired")
+	if len(r.calls) != 1 {
+		t.Errorf("runner calls = %q, want only the run lookup", r.calls)
+	}
+}
+
+func TestEmitEvent_NoActiveRunRechecked(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.": {err: errors.New("exit status 1")},
+	}}
+	c := New(
+		WithEnv(map[string]string{}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+		WithClock(func() time.Time { return now }),
+		WithProbeTTL(time.Minute),
+	)
+
+	c.EmitEvent("", "hook.fired")
+	c.EmitEvent("", "hook.fired") // within the TTL: no second lookup
+	if len(r.calls) != 1 {
+		t.Fatalf("runner calls = %q, want one run lookup", r.calls)
+	}
+
+	// A sprint starts after the server did.
+	r.mu.Lock()
+	r.results["ic run current --project=."] = fakeResult{stdout: "run-9"}
+	r.results["ic events emit run-9 hook.fired --payload={}"] = fakeResult{}
+	r.mu.Unlock()
+	now = now.Add(time.Minute)
+	c.EmitEvent("", "hook.fired")
+	if got := r.calls[len(r.calls)-1]; got != "ic events emit run-9 hook.fired --payload={}" {
+		t.Errorf("last call = %q, want the emit to the new run", got)
+	}
+}
+
+func TestEmitEvent_FollowsNewRun(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &fakeRunner{results: map[string]fakeResult{
+		"ic run current --project=.":                   {stdout: "run-1"},
+		"ic events emit run-1 hook.fired --payload={}": {},
+		"ic events emit run-2 hook.fired --payload={}": {},
+	}}
+	c := New(
+		WithEnv(map[string]string{}),
+		WithLookPath(onPath("ic")),
+		WithRunner(r),
+		WithClock(func() time.Time { return now }),
+		WithProbeTTL(time.Minute),
+	)
+
+	c.EmitEvent("", "hook.fired")
+
+	// The sprint ends and the next one starts while the server runs.
+	r.mu.Lock()
+	r.results["ic run current --project=."] = fakeResult{stdout: "run-2"}
+	r.mu.Unlock()
+	now = now.Add(time.Hour)
+	c.EmitEvent("", "hook.fired")
+	if got := r.calls[len(r.calls)-1]; got != "ic events emit run-2 hook.fired --payload={}" {
+		t.Errorf("last call = %q, want the emit to the new run", got)
+	}
+	if run, _ := c.CurrentRun(); run.ID != "run-2" {
+		t.Errorf("CurrentRun().ID = %q, want run-2", run.ID)
+	}
+}
@@ -0,0 +1,166 @@
+package interbase
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Runner executes ic and bd subprocesses on behalf of a Client. name is the
+// bare tool name ("ic", "bd"); resolving it is the Runner's job. Every call
+// guards and actions make goes through the Client's Runner. Whether a tool is
+// there at all (HasIC, HasBD) is decided by the Client's PATH lookup, see
+// WithLookPath, unless the Runner implements AvailableRunner: a Runner that
+// needs no binary, such as an in-process intercore client, should.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+// AvailableRunner is a Runner that can serve some tools without them being
+// on PATH. When Available(name) is true the Client treats name as present;
+// false defers to the Client's PATH lookup.
+type AvailableRunner interface {
+	Runner
+	Available(name string) bool
+}
+
+// RunnerFunc adapts a function to the Runner interface.
+type RunnerFunc func(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+
+// Run calls f.
+func (f RunnerFunc) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return f(ctx, name, args...)
+}
+
+// ExecRunner is the default Runner: one real subprocess per call, killed
+// when ctx is done.
+type ExecRunner struct {
+	// LookPath resolves the tool name (default exec.LookPath).
+	LookPath func(file string) (string, error)
+	// Env is the subprocess environment; nil inherits the process's.
+	Env []string
+}
+
+// Run implements Runner.
+func (r ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	lookPath := r.LookPath
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	path, err := lookPath(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := command(ctx, path, args...)
+	cmd.Env = r.Env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// Call is one invocation seen by a RecordingRunner.
+type Call struct {
+	Name   string
+	Args   []string
+	Stdout []byte
+	Stderr []byte
+	Err    error
+}
+
+// String renders the command line, e.g. "bd set-state iv-1 phase=done".
+func (c Call) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// RecordingRunner records every call before passing it to Next. With a nil
+// Next every call succeeds with no output and every tool is available, which
+// is enough for testing that an action ran the right command without ic or bd
+// installed. Otherwise a tool is available as Next says (see AvailableRunner).
+// Safe for concurrent use.
+type RecordingRunner struct {
+	Next Runner
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Run implements Runner.
+func (r *RecordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var stdout, stderr []byte
+	var err error
+	if r.Next != nil {
+		stdout, stderr, err = r.Next.Run(ctx, name, args...)
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, Call{
+		Name:   name,
+		Args:   append([]string(nil), args...),
+		Stdout: stdout,
+		Stderr: stderr,
+		Err:    err,
+	})
+	r.mu.Unlock()
+	return stdout, stderr, err
+}
+
+// Available implements AvailableRunner.
+func (r *RecordingRunner) Available(name string) bool {
+	if r.Next == nil {
+		return true
+	}
+	next, ok := r.Next.(AvailableRunner)
+	return ok && next.Available(name)
+}
+
+// Calls returns the calls recorded so far, oldest first.
+func (r *RecordingRunner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Commands returns the recorded command lines, oldest first.
+func (r *RecordingRunner) Commands() []string {
+	calls := r.Calls()
+	lines := make([]string, len(calls))
+	for i, c := range calls {
+		lines[i] = c.String()
+	}
+	return lines
+}
+
+// Reset forgets the recorded calls.
+func (r *RecordingRunner) Reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.mu.Unlock()
+}
+
+// SetRunner replaces the default Client's Runner; nil restores ExecRunner.
+// Cached probe results are discarded, since they came from the old Runner.
+func SetRunner(r Runner) {
+	defaultClient.SetRunner(r)
+}
+
+// SetRunner is SetRunner for c.
+func (c *Client) SetRunner(r Runner) {
+	if r == nil {
+		r = c.execRunner()
+	}
+	c.runner.Store(&runnerHolder{r})
+	c.Refresh()
+}
+
+// runnerHolder lets a Runner of any concrete type sit in an atomic.Pointer.
+type runnerHolder struct {
+	Runner
+}
+
+// execRunner is the ExecRunner bound to c's PATH resolution and environment.
+func (c *Client) execRunner() Runner {
+	return ExecRunner{LookPath: c.lookPath, Env: c.environ}
+}
@@ -0,0 +1,111 @@
+package interbase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordingRunner_Actions(t *testing.T) {
+	r := &RecordingRunner{}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd", "ic")), WithRunner(r))
+
+	c.BeadStateSet("iv-1", "review", "pending", "queued")
+	c.EmitEvent("run-1", "a.one")
+
+	want := []string{
+		"bd set-state iv-1 review=pending --reason queued",
+		"ic events emit run-1 a.one --payload={}",
+	}
+	if got := r.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+	r.Reset()
+	if n := len(r.Calls()); n != 0 {
+		t.Errorf("Calls() after Reset = %d, want 0", n)
+	}
+}
+
+func TestRecordingRunner_NoBinaries(t *testing.T) {
+	r := &RecordingRunner{}
+	c := New(WithEnv(map[string]string{"PATH": "/nonexistent"}), WithRunner(r))
+
+	c.BeadStateSet("iv-1", "owner", "me")
+	if got := r.Commands(); !reflect.DeepEqual(got, []string{"bd set-state iv-1 owner=me"}) {
+		t.Errorf("Commands() = %q, want the action recorded without bd on PATH", got)
+	}
+}
+
+// inProcessRunner serves ic itself and nothing else.
+type inProcessRunner struct{ RunnerFunc }
+
+func (inProcessRunner) Available(name string) bool { return name == "ic" }
+
+func TestAvailableRunner(t *testing.T) {
+	r := inProcessRunner{func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		return nil, nil, nil
+	}}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	if !c.HasIC() || !c.HasBD() {
+		t.Errorf("HasIC, HasBD = %v, %v; want ic from the runner and bd from PATH", c.HasIC(), c.HasBD())
+	}
+	c.SetRunner(RunnerFunc(r.RunnerFunc))
+	if c.HasIC() {
+		t.Error("HasIC with a plain Runner and no ic on PATH = true")
+	}
+}
+
+func TestRecordingRunner_Next(t *testing.T) {
+	fail := errors.New("exit status 1")
+	r := &RecordingRunner{Next: RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		if name == "bd" {
+			return nil, []byte("locked"), fail
+		}
+		return []byte("ok"), nil, nil
+	})}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("bd")), WithRunner(r))
+
+	if err := c.BeadStateSetE("iv-1", "owner", "me"); !errors.Is(err, fail) {
+		t.Errorf("BeadStateSetE = %v, want the Next runner's error", err)
+	}
+	calls := r.Calls()
+	if len(calls) != 1 || calls[0].Err != fail || string(calls[0].Stderr) != "locked" {
+		t.Errorf("Calls() = %+v, want one failed bd call with stderr", calls)
+	}
+}
+
+func TestSetRunner(t *testing.T) {
+	r := &RecordingRunner{Next: RunnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+		return []byte("ic version 9.9.9"), nil, nil
+	})}
+	c := New(WithEnv(map[string]string{}), WithLookPath(onPath("ic")))
+	c.SetRunner(r)
+
+	if got := c.ICVersion(); got != "9.9.9" {
+		t.Errorf("ICVersion() = %q, want 9.9.9 from the swapped runner", got)
+	}
+	c.SetRunner(nil)
+	if _, ok := c.runner.Load().Runner.(ExecRunner); !ok {
+		t.Errorf("SetRunner(nil) left %T, want ExecRunner", c.runner.Load().Runner)
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "bd", `echo "out $1"; echo "err" >&2; exit 2`)
+	r := ExecRunner{
+		LookPath: func(file string) (string, error) { return lookPathIn(file, dir) },
+		Env:      []string{"PATH=" + dir},
+	}
+	stdout, stderr, err := r.Run(context.Background(), "bd", "x")
+	if strings.TrimSpace(string(stdout)) != "out x" || strings.TrimSpace(string(stderr)) != "err" {
+		t.Errorf("Run() = %q, %q", stdout, stderr)
+	}
+	var exit interface{ ExitCode() int }
+	if !errors.As(err, &exit) || exit.ExitCode() != 2 {
+		t.Errorf("Run() err = %v, want exit status 2", err)
+	}
+}
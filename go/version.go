@@ -0,0 +1,498 @@
+package interbase
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Version guards ---
+
+// ICVersion returns the installed ic version (e.g. "0.4.2"), or empty string
+// if ic is missing or its --version output cannot be parsed. Cached like HasIC.
+func ICVersion() string {
+	return defaultClient.ICVersion()
+}
+
+// ICVersion is ICVersion for c.
+func (c *Client) ICVersion() string {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.ICVersionContext(ctx)
+}
+
+// ICVersionContext is ICVersion bounded by ctx.
+func ICVersionContext(ctx context.Context) string {
+	return defaultClient.ICVersionContext(ctx)
+}
+
+// ICVersionContext is ICVersionContext for c.
+func (c *Client) ICVersionContext(ctx context.Context) string {
+	return c.toolVersion(ctx, "ic")
+}
+
+// BDVersion returns the installed bd version, or empty string if bd is missing
+// or its --version output cannot be parsed. Cached like HasBD.
+func BDVersion() string {
+	return defaultClient.BDVersion()
+}
+
+// BDVersion is BDVersion for c.
+func (c *Client) BDVersion() string {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.BDVersionContext(ctx)
+}
+
+// BDVersionContext is BDVersion bounded by ctx.
+func BDVersionContext(ctx context.Context) string {
+	return defaultClient.BDVersionContext(ctx)
+}
+
+// BDVersionContext is BDVersionContext for c.
+func (c *Client) BDVersionContext(ctx context.Context) string {
+	return c.toolVersion(ctx, "bd")
+}
+
+// HasICVersion returns true if ic is installed and its version satisfies
+// constraint (e.g. ">=0.4.0", ">=0.4, <1.0"). Returns false if ic is missing,
+// its version is unparsable, or the constraint is invalid.
+func HasICVersion(constraint string) bool {
+	return defaultClient.HasICVersion(constraint)
+}
+
+// HasICVersion is HasICVersion for c.
+func (c *Client) HasICVersion(constraint string) bool {
+	return versionSatisfies(c.ICVersion(), constraint)
+}
+
+// HasICVersionContext is HasICVersion bounded by ctx.
+func HasICVersionContext(ctx context.Context, constraint string) bool {
+	return defaultClient.HasICVersionContext(ctx, constraint)
+}
+
+// HasICVersionContext is HasICVersionContext for c.
+func (c *Client) HasICVersionContext(ctx context.Context, constraint string) bool {
+	return versionSatisfies(c.ICVersionContext(ctx), constraint)
+}
+
+// HasBDVersion is HasICVersion for bd.
+func HasBDVersion(constraint string) bool {
+	return defaultClient.HasBDVersion(constraint)
+}
+
+// HasBDVersion is HasBDVersion for c.
+func (c *Client) HasBDVersion(constraint string) bool {
+	return versionSatisfies(c.BDVersion(), constraint)
+}
+
+// HasBDVersionContext is HasBDVersion bounded by ctx.
+func HasBDVersionContext(ctx context.Context, constraint string) bool {
+	return defaultClient.HasBDVersionContext(ctx, constraint)
+}
+
+// HasBDVersionContext is HasBDVersionContext for c.
+func (c *Client) HasBDVersionContext(ctx context.Context, constraint string) bool {
+	return versionSatisfies(c.BDVersionContext(ctx), constraint)
+}
+
+// toolVersion runs `name --version` once per probe TTL and extracts the version.
+func (c *Client) toolVersion(ctx context.Context, name string) string {
+	if !c.hasBinary(name) {
+		return ""
+	}
+	key := "version\x00" + name + "\x00" + c.getenv("PATH")
+	v, _ := c.probes.do(ctx, key, func() (string, bool) {
+		out, _, err := c.run(ctx, name, "--version")
+		if err != nil {
+			return "", false
+		}
+		v, ok := extractVersion(string(out))
+		if !ok {
+			return "", false
+		}
+		return v.String(), true
+	})
+	return v
+}
+
+func versionSatisfies(installed, constraint string) bool {
+	if installed == "" {
+		return false
+	}
+	v, ok := parseVersion(installed)
+	if !ok {
+		return false
+	}
+	c, ok := parseConstraint(constraint)
+	if !ok {
+		return false
+	}
+	return c.matches(v)
+}
+
+// --- Semantic versions ---
+
+// version is a parsed semantic version. Missing minor/patch parts are zero;
+// build metadata is dropped because it does not affect precedence.
+type version struct {
+	major, minor, patch int
+	pre                 string
+}
+
+var versionRe = regexp.MustCompile(`v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?`)
+
+// extractVersion finds the first dotted version in free-form tool output such
+// as "ic version 0.4.2 (abc123)". At least major.minor is required so that
+// stray integers are not mistaken for versions.
+func extractVersion(s string) (version, bool) {
+	for _, m := range versionRe.FindAllStringSubmatch(s, -1) {
+		if m[2] == "" {
+			continue
+		}
+		return versionFromMatch(m), true
+	}
+	return version{}, false
+}
+
+// parseVersion parses a complete version string like "1.2.3", "v1.2" or
+// "1.0.0-rc.1+build5".
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimSpace(s)
+	m := versionRe.FindStringSubmatch(s)
+	if m == nil || m[0] != s {
+		return version{}, false
+	}
+	return versionFromMatch(m), true
+}
+
+func versionFromMatch(m []string) version {
+	var v version
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+	v.pre = m[4]
+	return v
+}
+
+func (v version) String() string {
+	s := strconv.Itoa(v.major) + "." + strconv.Itoa(v.minor) + "." + strconv.Itoa(v.patch)
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	return s
+}
+
+// compare returns -1, 0 or 1 following semver precedence rules.
+func (v version) compare(o version) int {
+	if c := compareInt(v.major, o.major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.minor, o.minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.patch, o.patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.pre, o.pre)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease orders prerelease tags: a release sorts after any
+// prerelease, numeric identifiers compare numerically and sort before
+// alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(as), len(bs))
+}
+
+// --- Constraints ---
+
+// comparator is a single operator/version pair such as ">=0.4.0".
+type comparator struct {
+	op string
+	v  version
+}
+
+// constraint is a disjunction of comparator sets: it matches a version if
+// every comparator in at least one set matches.
+type constraint [][]comparator
+
+var (
+	comparatorRe = regexp.MustCompile(`^(>=|<=|!=|==|=|>|<|\^|~>|~)?\s*(\S+)$`)
+	partialRe    = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// parseConstraint parses npm-style version constraints:
+//
+//	>=0.4.0            comparison (=, !=, >, >=, <, <=)
+//	>= 0.4, <1         comparators joined by commas or spaces must all match
+//	1.2, 1.2.x, *      partial and wildcard versions match the whole range
+//	^1.2.3, ~1.2       caret (same major, or same minor below 1.0) and tilde
+//	1.2 - 1.4          inclusive hyphen range
+//	^1.0 || ^2.0       alternatives
+func parseConstraint(s string) (constraint, bool) {
+	var c constraint
+	for _, alt := range strings.Split(s, "||") {
+		set, ok := parseComparatorSet(alt)
+		if !ok {
+			return nil, false
+		}
+		c = append(c, set)
+	}
+	return c, true
+}
+
+func parseComparatorSet(s string) ([]comparator, bool) {
+	if lo, hi, ok := strings.Cut(s, " - "); ok {
+		return hyphenRange(strings.TrimSpace(lo), strings.TrimSpace(hi))
+	}
+	fields := splitComparators(s)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	var set []comparator
+	for _, f := range fields {
+		cmps, ok := expandComparator(f)
+		if !ok {
+			return nil, false
+		}
+		set = append(set, cmps...)
+	}
+	return set, true
+}
+
+// splitComparators splits on commas and whitespace, re-attaching an operator
+// written apart from its version (">= 0.4").
+func splitComparators(s string) []string {
+	raw := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	var out []string
+	for i := 0; i < len(raw); i++ {
+		f := raw[i]
+		if strings.Trim(f, "<>=!^~") == "" && i+1 < len(raw) {
+			f += raw[i+1]
+			i++
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// expandComparator turns one operator/partial-version token into plain
+// comparators, resolving partial versions, wildcards, caret and tilde.
+func expandComparator(f string) ([]comparator, bool) {
+	m := comparatorRe.FindStringSubmatch(f)
+	if m == nil {
+		return nil, false
+	}
+	lo, n, ok := parsePartial(m[2])
+	if !ok {
+		return nil, false
+	}
+	hi := bumpPartial(lo, n)
+	always := []comparator{{op: ">=", v: version{}}}
+	never := []comparator{{op: "<", v: version{}}}
+
+	switch m[1] {
+	case "", "=", "==":
+		switch n {
+		case 0:
+			return always, true
+		case 3:
+			return []comparator{{op: "=", v: lo}}, true
+		}
+		return []comparator{{op: ">=", v: lo}, {op: "<", v: hi}}, true
+	case "!=":
+		if n != 3 {
+			return nil, false
+		}
+		return []comparator{{op: "!=", v: lo}}, true
+	case ">":
+		switch n {
+		case 0:
+			return never, true
+		case 3:
+			return []comparator{{op: ">", v: lo}}, true
+		}
+		return []comparator{{op: ">=", v: hi}}, true
+	case ">=":
+		return []comparator{{op: ">=", v: lo}}, true
+	case "<":
+		if n == 0 {
+			return never, true
+		}
+		return []comparator{{op: "<", v: lo}}, true
+	case "<=":
+		switch n {
+		case 0:
+			return always, true
+		case 3:
+			return []comparator{{op: "<=", v: lo}}, true
+		}
+		return []comparator{{op: "<", v: hi}}, true
+	case "^":
+		if n == 0 {
+			return always, true
+		}
+		var upper version
+		switch {
+		case lo.major > 0 || n == 1:
+			upper = version{major: lo.major + 1}
+		case lo.minor > 0 || n == 2:
+			upper = version{minor: lo.minor + 1}
+		default:
+			upper = version{patch: lo.patch + 1}
+		}
+		return []comparator{{op: ">=", v: lo}, {op: "<", v: upper}}, true
+	case "~", "~>":
+		if n == 0 {
+			return always, true
+		}
+		upper := version{major: lo.major, minor: lo.minor + 1}
+		if n == 1 {
+			upper = version{major: lo.major + 1}
+		}
+		return []comparator{{op: ">=", v: lo}, {op: "<", v: upper}}, true
+	}
+	return nil, false
+}
+
+// hyphenRange resolves "LO - HI": LO is an inclusive lower bound and a
+// partial HI covers its whole range (1.2 - 1.4 allows 1.4.9).
+func hyphenRange(lo, hi string) ([]comparator, bool) {
+	lv, ln, ok := parsePartial(lo)
+	if !ok {
+		return nil, false
+	}
+	hv, hn, ok := parsePartial(hi)
+	if !ok {
+		return nil, false
+	}
+	var set []comparator
+	if ln > 0 {
+		set = append(set, comparator{op: ">=", v: lv})
+	}
+	switch hn {
+	case 0:
+	case 3:
+		set = append(set, comparator{op: "<=", v: hv})
+	default:
+		set = append(set, comparator{op: "<", v: bumpPartial(hv, hn)})
+	}
+	if len(set) == 0 {
+		set = append(set, comparator{op: ">=", v: version{}})
+	}
+	return set, true
+}
+
+// parsePartial parses a possibly incomplete version ("1", "1.2", "1.x", "*")
+// and reports how many leading numeric parts were given (0-3). Parts after a
+// wildcard are ignored.
+func parsePartial(s string) (version, int, bool) {
+	m := partialRe.FindStringSubmatch(s)
+	if m == nil {
+		return version{}, 0, false
+	}
+	var nums [3]int
+	n := 0
+	for i, p := range m[1:4] {
+		num, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		nums[i] = num
+		n++
+	}
+	v := version{major: nums[0], minor: nums[1], patch: nums[2]}
+	if n == 3 {
+		v.pre = m[4]
+	}
+	return v, n, true
+}
+
+// bumpPartial returns the exclusive upper bound of a partial version with n
+// given parts: 1.2 (n=2) becomes 1.3.0, 1 (n=1) becomes 2.0.0.
+func bumpPartial(v version, n int) version {
+	switch n {
+	case 1:
+		return version{major: v.major + 1}
+	case 2:
+		return version{major: v.major, minor: v.minor + 1}
+	}
+	return v
+}
+
+func (c constraint) matches(v version) bool {
+	for _, set := range c {
+		if setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func setMatches(set []comparator, v version) bool {
+	for _, cmp := range set {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c comparator) matches(v version) bool {
+	r := v.compare(c.v)
+	switch c.op {
+	case "=":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	}
+	return false
+}
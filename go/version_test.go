@@ -0,0 +1,166 @@
+package interbase
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExtractVersion(t *testing.T) {
+	tests := []struct {
+		out  string
+		want string
+		ok   bool
+	}{
+		{"ic version 0.4.2\n", "0.4.2", true},
+		{"bd v1.10.0 (abc1234)", "1.10.0", true},
+		{"ic 2.1", "2.1.0", true},
+		{"intercore 0.5.0-rc.1+build7", "0.5.0-rc.1", true},
+		{"build 42", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := extractVersion(tt.out)
+		if ok != tt.ok {
+			t.Errorf("extractVersion(%q) ok = %v, want %v", tt.out, ok, tt.ok)
+			continue
+		}
+		if ok && v.String() != tt.want {
+			t.Errorf("extractVersion(%q) = %q, want %q", tt.out, v.String(), tt.want)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9.0", "1.10.0", -1},
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"1.0.0-beta", "1.0.0-alpha", 1},
+		{"2.0", "1.99.99", 1},
+	}
+	for _, tt := range tests {
+		a, _ := parseVersion(tt.a)
+		b, _ := parseVersion(tt.b)
+		if got := a.compare(b); got != tt.want {
+			t.Errorf("compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		installed, constraint string
+		want                  bool
+	}{
+		{"0.4.0", ">=0.4.0", true},
+		{"0.3.9", ">=0.4.0", false},
+		{"0.4.2", ">= 0.4, <1.0", true},
+		{"1.0.0", ">=0.4 <1.0", false},
+		{"0.4.2", "0.4.2", true},
+		{"0.4.2", "!=0.4.2", false},
+		{"0.4.2", "", false},
+		{"0.4.2", ">=banana", false},
+		{"", ">=0.1", false},
+	}
+	for _, tt := range tests {
+		if got := versionSatisfies(tt.installed, tt.constraint); got != tt.want {
+			t.Errorf("versionSatisfies(%q, %q) = %v, want %v", tt.installed, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestConstraintRanges(t *testing.T) {
+	tests := []struct {
+		constraint string
+		yes, no    []string
+	}{
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^1.2", []string{"1.2.0", "1.99.0"}, []string{"1.1.9", "2.0.0"}},
+		{"^0", []string{"0.0.1", "0.9.9"}, []string{"1.0.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{"1.2 - 1.4", []string{"1.2.0", "1.4.9"}, []string{"1.1.9", "1.5.0"}},
+		{"1.2.3 - 1.4.0", []string{"1.2.3", "1.4.0"}, []string{"1.4.1"}},
+		{"1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1.x", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, nil},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"^1.0 || ^3.0", []string{"1.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{">=1.2 <1.4 || 2.x", []string{"1.3.0", "2.5.0"}, []string{"1.4.0", "3.0.0"}},
+	}
+	for _, tt := range tests {
+		for _, v := range tt.yes {
+			if !versionSatisfies(v, tt.constraint) {
+				t.Errorf("%q should satisfy %q", v, tt.constraint)
+			}
+		}
+		for _, v := range tt.no {
+			if versionSatisfies(v, tt.constraint) {
+				t.Errorf("%q should not satisfy %q", v, tt.constraint)
+			}
+		}
+	}
+}
+
+func TestConstraintInvalid(t *testing.T) {
+	for _, c := range []string{"", "^", ">=1.2 ||", "1.2 -", "~>banana", "!=1.2"} {
+		if _, ok := parseConstraint(c); ok {
+			t.Errorf("parseConstraint(%q) ok = true, want false", c)
+		}
+	}
+}
+
+func TestHasICVersion_FakeIC(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", `echo "ic version 0.4.2"`)
+	t.Setenv("PATH", dir)
+
+	if got := ICVersion(); got != "0.4.2" {
+		t.Errorf("ICVersion() = %q, want 0.4.2", got)
+	}
+	if !HasICVersion(">=0.4.0") {
+		t.Error("HasICVersion(>=0.4.0) = false, want true")
+	}
+	if HasICVersion(">=0.5.0") {
+		t.Error("HasICVersion(>=0.5.0) = true, want false")
+	}
+}
+
+func TestHasICVersion_Unparsable(t *testing.T) {
+	Refresh()
+	t.Cleanup(Refresh)
+	dir := t.TempDir()
+	writeFakeBin(t, dir, "ic", `echo "intercore dev build"`)
+	t.Setenv("PATH", dir)
+
+	if got := ICVersion(); got != "" {
+		t.Errorf("ICVersion() = %q, want empty for unparsable output", got)
+	}
+	if HasICVersion(">=0.0.0") {
+		t.Error("HasICVersion = true for unparsable output, want false (fail-open)")
+	}
+}
+
+func TestBDVersion_Missing(t *testing.T) {
+	old := os.Getenv("PATH")
+	t.Setenv("PATH", "")
+	defer os.Setenv("PATH", old)
+
+	if got := BDVersion(); got != "" {
+		t.Errorf("BDVersion() = %q, want empty (no bd)", got)
+	}
+	if HasBDVersion(">=0.0.0") {
+		t.Error("HasBDVersion = true, want false (no bd)")
+	}
+}